@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"linebot-garbage-helper/internal/config"
+	"linebot-garbage-helper/internal/garbage"
+)
+
+// runExportGTFS implements the export-gtfs subcommand. It only needs the data
+// source and calendar settings, so it runs without LINE or GCP credentials.
+func runExportGTFS(args []string) error {
+	fs := flag.NewFlagSet("export-gtfs", flag.ExitOnError)
+	output := fs.String("o", "gtfs.zip", "output zip file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: server export-gtfs [-o gtfs.zip]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+	garbageAdapter, err := newGarbageAdapter(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	data, err := garbageAdapter.GetGarbageData(ctx)
+	if err != nil {
+		return err
+	}
+
+	feed := garbage.BuildGTFSFeed(data, garbageAdapter.Calendar(), time.Now())
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+
+	if err := feed.WriteZip(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	log.Printf("GTFS feed written to %s: %d stops, %d trips, %d stop times",
+		*output, len(feed.Stops)-1, len(feed.Trips)-1, len(feed.StopTimes)-1)
+	return nil
+}
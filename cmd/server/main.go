@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -23,6 +25,13 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "export-gtfs" {
+		if err := runExportGTFS(os.Args[2:]); err != nil {
+			log.Fatalf("GTFS export failed: %v", err)
+		}
+		return
+	}
+
 	log.Println("Starting garbage LINE bot server...")
 
 	cfg := config.Load()
@@ -40,37 +49,87 @@ func main() {
 	}
 	defer firestoreClient.Close()
 
-	geoClient, err := geo.NewGeocodeClient(cfg.GoogleMapsAPIKey)
+	garbageAdapter, err := newGarbageAdapter(cfg)
 	if err != nil {
-		log.Fatalf("Failed to create geocoding client: %v", err)
+		log.Fatalf("Failed to create garbage adapter: %v", err)
 	}
 
-	garbageAdapter := garbage.NewGarbageAdapter()
-
-	geminiClient, err := gemini.NewGeminiClient(ctx, cfg.GeminiAPIKey, cfg.GeminiModel)
+	offlineGeocoder, err := newOfflineGeocoder(cfg)
 	if err != nil {
-		log.Fatalf("Failed to create Gemini client: %v", err)
+		log.Fatalf("Failed to create offline geocoder: %v", err)
+	}
+	garbageAdapter.OnRefresh(func(ctx context.Context, data *garbage.GarbageData) {
+		offlineGeocoder.SetAreas(data.Gazetteer().Places())
+	})
+
+	// 沒有 Maps API key 時只使用離線地理編碼；有的話離線地理編碼作為額度用完時的備援
+	var geocoder geo.Geocoder = offlineGeocoder
+	var geoClient *geo.GeocodeClient
+	if cfg.GoogleMapsAPIKey != "" {
+		geoClient, err = geo.NewGeocodeClient(cfg.GoogleMapsAPIKey)
+		if err != nil {
+			log.Fatalf("Failed to create geocoding client: %v", err)
+		}
+		cacheConfig := geo.CacheConfig{
+			TTL:        time.Duration(cfg.GeocodeCacheTTLHours) * time.Hour,
+			ReverseTTL: time.Duration(cfg.ReverseCacheTTLHours) * time.Hour,
+		}
+		if cfg.GeocodeCachePersist {
+			cacheConfig.Store = firestoreClient
+		}
+		geoClient.SetCache(geo.NewGeocodeCache(cacheConfig))
+		geocoder = geo.NewFallbackGeocoder(geoClient, offlineGeocoder)
+	} else {
+		log.Println("Warning: GOOGLE_MAPS_API_KEY is not set, geocoding runs offline with district and 里 centroids only")
+	}
+
+	// NLU_MODE：auto 以 Gemini 為主、規則式分析為備援；gemini 只用 Gemini；rules 只用規則式分析
+	ruleAnalyzer := gemini.NewRuleAnalyzer()
+	var analyzer gemini.Analyzer = ruleAnalyzer
+	switch {
+	case cfg.NLUMode == "rules":
+		log.Println("NLU_MODE is rules, messages are analyzed with rules only")
+	case cfg.GeminiAPIKey == "" && cfg.NLUMode == "gemini":
+		log.Fatalf("NLU_MODE is gemini but GEMINI_API_KEY is not set")
+	case cfg.GeminiAPIKey == "":
+		log.Println("Warning: GEMINI_API_KEY is not set, messages are analyzed with rules only")
+	default:
+		geminiClient, err := gemini.NewGeminiClient(ctx, cfg.GeminiAPIKey, cfg.GeminiModel)
+		if err != nil {
+			log.Fatalf("Failed to create Gemini client: %v", err)
+		}
+		defer geminiClient.Close()
+
+		analyzer = geminiClient
+		if cfg.NLUMode != "gemini" {
+			analyzer = gemini.NewFallbackAnalyzer(geminiClient, ruleAnalyzer)
+		}
 	}
-	defer geminiClient.Close()
 
 	lineHandler, err := line.NewHandler(
 		cfg.LineChannelAccessToken,
 		cfg.LineChannelSecret,
 		firestoreClient,
-		geoClient,
+		geocoder,
 		garbageAdapter,
-		geminiClient,
+		analyzer,
 	)
 	if err != nil {
 		log.Fatalf("Failed to create LINE handler: %v", err)
 	}
+	lineHandler.SetSearchRadius(float64(cfg.SearchRadiusMeters))
+	lineHandler.SetCommuteCorridor(float64(cfg.CommuteCorridorMeters))
 
-	reminderScheduler := reminder.NewScheduler(firestoreClient, lineHandler.GetMessagingAPI())
+	reminderScheduler := reminder.NewScheduler(firestoreClient, lineHandler.GetMessagingAPI(), garbageAdapter)
 	reminderService := reminder.NewReminderService(reminderScheduler)
 
+	changeNotifier := reminder.NewChangeNotifier(firestoreClient, lineHandler.GetMessagingAPI())
+	garbageAdapter.OnRefresh(changeNotifier.HandleRefresh)
+
 	go reminderScheduler.StartScheduler(ctx)
+	go garbageAdapter.StartRefresher(ctx, time.Duration(cfg.GarbageRefreshMinutes)*time.Minute)
 
-	server := setupServer(cfg, lineHandler, reminderService)
+	server := setupServer(cfg, lineHandler, reminderService, garbageAdapter, geoClient)
 
 	go func() {
 		log.Printf("Server starting on port %s", cfg.Port)
@@ -82,7 +141,7 @@ func main() {
 	waitForShutdown(ctx, server)
 }
 
-func setupServer(cfg *config.Config, lineHandler *line.Handler, reminderService *reminder.ReminderService) *http.Server {
+func setupServer(cfg *config.Config, lineHandler *line.Handler, reminderService *reminder.ReminderService, garbageAdapter *garbage.GarbageAdapter, geoClient *geo.GeocodeClient) *http.Server {
 	r := mux.NewRouter()
 
 	// Add middleware to log all requests
@@ -96,9 +155,7 @@ func setupServer(cfg *config.Config, lineHandler *line.Handler, reminderService
 	r.HandleFunc("/line/callback", lineHandler.HandleWebhook).Methods("POST")
 
 	r.HandleFunc("/tasks/dispatch-reminders", func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("Authorization")
-		if token != "Bearer "+cfg.InternalTaskToken {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		if !authorizeInternal(cfg, w, r) {
 			return
 		}
 
@@ -114,16 +171,84 @@ func setupServer(cfg *config.Config, lineHandler *line.Handler, reminderService
 	}).Methods("POST")
 
 	r.HandleFunc("/internal/refresh-routes", func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("Authorization")
-		if token != "Bearer "+cfg.InternalTaskToken {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		if !authorizeInternal(cfg, w, r) {
+			return
+		}
+
+		if err := garbageAdapter.Refresh(r.Context()); err != nil {
+			log.Printf("Error refreshing garbage data: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
 
+		data, err := garbageAdapter.GetGarbageData(r.Context())
+		if err != nil {
+			log.Printf("Error reading garbage data snapshot: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("Routes refresh triggered"))
+		w.Write([]byte(fmt.Sprintf(`{"points":%d,"lastLoaded":"%s"}`,
+			len(data.Result.Results), garbageAdapter.LastLoaded().Format(time.RFC3339))))
 	}).Methods("POST")
 
+	r.HandleFunc("/internal/data-health", func(w http.ResponseWriter, r *http.Request) {
+		if !authorizeInternal(cfg, w, r) {
+			return
+		}
+
+		report := garbageAdapter.HealthReport()
+		if report == nil {
+			http.Error(w, "Garbage data not loaded yet", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"lastLoaded": garbageAdapter.LastLoaded().Format(time.RFC3339),
+			"report":     report,
+		})
+	}).Methods("GET")
+
+	r.HandleFunc("/internal/geocode-cache", func(w http.ResponseWriter, r *http.Request) {
+		if !authorizeInternal(cfg, w, r) {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(geoClient.CacheStats())
+	}).Methods("GET")
+
+	r.HandleFunc("/internal/export/gtfs.zip", func(w http.ResponseWriter, r *http.Request) {
+		if !authorizeInternal(cfg, w, r) {
+			return
+		}
+
+		data, err := garbageAdapter.GetGarbageData(r.Context())
+		if err != nil {
+			log.Printf("Error loading garbage data for GTFS export: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		// Build the archive in memory so a failure can still be reported as a 500
+		var buf bytes.Buffer
+		if err := garbage.BuildGTFSFeed(data, garbageAdapter.Calendar(), time.Now()).WriteZip(&buf); err != nil {
+			log.Printf("Error writing GTFS export: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="gtfs.zip"`)
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+	}).Methods("GET")
+
 	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
@@ -144,12 +269,67 @@ func setupServer(cfg *config.Config, lineHandler *line.Handler, reminderService
 	}
 }
 
+// authorizeInternal checks the internal task token and writes a 401 when it does not match.
+func authorizeInternal(cfg *config.Config, w http.ResponseWriter, r *http.Request) bool {
+	token := r.Header.Get("Authorization")
+	if token != "Bearer "+cfg.InternalTaskToken {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// newGarbageAdapter creates the adapter for the configured providers and loads
+// the collection calendar rules and holidays.
+func newGarbageAdapter(cfg *config.Config) (*garbage.GarbageAdapter, error) {
+	garbageAdapter := garbage.NewGarbageAdapter(buildProviders(cfg)...)
+	if cfg.CollectionCalendarFile != "" {
+		if err := garbageAdapter.Calendar().LoadCalendarRules(cfg.CollectionCalendarFile); err != nil {
+			return nil, fmt.Errorf("failed to load collection calendar rules: %w", err)
+		}
+	}
+	if err := garbageAdapter.Calendar().LoadHolidayDir(cfg.HolidayCalendarDir); err != nil {
+		return nil, fmt.Errorf("failed to load holiday calendar: %w", err)
+	}
+	return garbageAdapter, nil
+}
+
+// newOfflineGeocoder loads the local address table, if there is one. Area
+// centroids are added once the collection data is loaded.
+func newOfflineGeocoder(cfg *config.Config) (*geo.OfflineGeocoder, error) {
+	table, err := geo.LoadAddressTable(cfg.OfflineAddressTable)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return geo.NewOfflineGeocoder(table), nil
+}
+
+// buildProviders returns the Taipei provider plus every other city whose data
+// source is configured.
+func buildProviders(cfg *config.Config) []garbage.Provider {
+	providers := []garbage.Provider{garbage.NewTaipeiProvider(cfg.TaipeiDataSource)}
+
+	if cfg.NewTaipeiDataSource != "" {
+		providers = append(providers, garbage.NewNTPCProvider(cfg.NewTaipeiDataSource))
+	}
+	if cfg.TaoyuanDataSource != "" {
+		providers = append(providers, garbage.NewTaoyuanProvider(cfg.TaoyuanDataSource))
+	}
+	if cfg.KaohsiungDataSource != "" {
+		providers = append(providers, garbage.NewKaohsiungProvider(cfg.KaohsiungDataSource))
+	}
+
+	for _, provider := range providers {
+		log.Printf("Collection data provider enabled: %s (%s)", provider.Name(), provider.City())
+	}
+
+	return providers
+}
+
 func validateConfig(cfg *config.Config) error {
 	required := map[string]string{
 		"LINE_CHANNEL_SECRET":        cfg.LineChannelSecret,
 		"LINE_CHANNEL_ACCESS_TOKEN":  cfg.LineChannelAccessToken,
-		"GOOGLE_MAPS_API_KEY":        cfg.GoogleMapsAPIKey,
-		"GEMINI_API_KEY":             cfg.GeminiAPIKey,
 		"GCP_PROJECT_ID":             cfg.GCPProjectID,
 	}
 
@@ -19,6 +19,20 @@ type Config struct {
 	GeminiModel            string
 	GCPProjectID           string
 	InternalTaskToken      string
+	GarbageRefreshMinutes  int
+	TaipeiDataSource       string
+	NewTaipeiDataSource    string
+	TaoyuanDataSource      string
+	KaohsiungDataSource    string
+	CollectionCalendarFile string
+	HolidayCalendarDir     string
+	SearchRadiusMeters     int
+	CommuteCorridorMeters  int
+	GeocodeCacheTTLHours   int
+	ReverseCacheTTLHours   int
+	GeocodeCachePersist    bool
+	OfflineAddressTable    string
+	NLUMode                string
 }
 
 func Load() *Config {
@@ -51,6 +65,20 @@ func Load() *Config {
 		GeminiModel:            getEnvOrDefault("GEMINI_MODEL", "gemini-2.0-flash"),
 		GCPProjectID:           os.Getenv("GCP_PROJECT_ID"),
 		InternalTaskToken:      internalTaskToken,
+		GarbageRefreshMinutes:  getEnvAsPositiveIntOrDefault("GARBAGE_DATA_REFRESH_MINUTES", 60),
+		TaipeiDataSource:       os.Getenv("TAIPEI_DATA_SOURCE"),
+		NewTaipeiDataSource:    os.Getenv("NEW_TAIPEI_DATA_SOURCE"),
+		TaoyuanDataSource:      os.Getenv("TAOYUAN_DATA_SOURCE"),
+		KaohsiungDataSource:    os.Getenv("KAOHSIUNG_DATA_SOURCE"),
+		CollectionCalendarFile: os.Getenv("COLLECTION_CALENDAR_FILE"),
+		HolidayCalendarDir:     getEnvOrDefault("HOLIDAY_CALENDAR_DIR", "data/holidays"),
+		SearchRadiusMeters:     getEnvAsIntOrDefault("SEARCH_RADIUS_METERS", 2000),
+		CommuteCorridorMeters:  getEnvAsIntOrDefault("COMMUTE_CORRIDOR_METERS", 300),
+		GeocodeCacheTTLHours:   getEnvAsIntOrDefault("GEOCODE_CACHE_TTL_HOURS", 720),
+		ReverseCacheTTLHours:   getEnvAsIntOrDefault("REVERSE_GEOCODE_CACHE_TTL_HOURS", 168),
+		GeocodeCachePersist:    getEnvOrDefault("GEOCODE_CACHE_PERSIST", "false") == "true",
+		OfflineAddressTable:    getEnvOrDefault("OFFLINE_ADDRESS_TABLE", "data/addresses.json"),
+		NLUMode:                getEnvOrDefault("NLU_MODE", "auto"),
 	}
 }
 
@@ -70,6 +98,17 @@ func getEnvAsIntOrDefault(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsPositiveIntOrDefault is like getEnvAsIntOrDefault but rejects zero and
+// negative values, which would otherwise panic when used as a ticker interval.
+func getEnvAsPositiveIntOrDefault(key string, defaultValue int) int {
+	value := getEnvAsIntOrDefault(key, defaultValue)
+	if value <= 0 {
+		log.Printf("Warning: %s must be positive, got %d; using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
 // getRandomString generates a random hex string as fallback
 func getRandomString(length int) string {
 	bytes := make([]byte, length/2)
@@ -2,24 +2,60 @@ package garbage
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
-
-	"linebot-garbage-helper/internal/geo"
-	"linebot-garbage-helper/internal/utils"
 )
 
 type GarbageAdapter struct {
 	httpClient *http.Client
+	providers  []Provider
+	calendar   *CollectionCalendar
+
+	mu         sync.RWMutex
+	data       *GarbageData
+	lastLoaded time.Time
+	health     *HealthReport
+
+	// refreshMu serializes downloads so concurrent cache misses only fetch once
+	refreshMu    sync.Mutex
+	refreshHooks []RefreshHook
+	generation   int
+
+	// hooksMu serializes refresh hooks, which run outside refreshMu
+	hooksMu          sync.Mutex
+	hookedGeneration int
 }
 
 type GarbageData struct {
 	Result GarbageResult `json:"result"`
+
+	// FailedCities lists the cities whose provider failed in this refresh. Their
+	// points, if any, were carried over from the previous snapshot.
+	FailedCities []string `json:"-"`
+
+	indexOnce sync.Once
+	index     *SpatialIndex
+
+	routesOnce sync.Once
+	routes     map[string]*Route
+
+	stopGroupsOnce sync.Once
+	stopGroups     map[string]*StopGroup
+
+	gazetteerOnce sync.Once
+	gazetteer     *Gazetteer
+
+	locationIndexOnce sync.Once
+	locationIndex     *LocationIndex
+
+	coverageOnce sync.Once
+	coverage     *Coverage
 }
 
 type GarbageResult struct {
@@ -30,21 +66,30 @@ type GarbageResult struct {
 	Results []CollectionPoint `json:"results"`
 }
 
+// CollectionPoint is one scheduled truck stop, normalized from a city's
+// open-data format by its Provider.
 type CollectionPoint struct {
-	ID          int         `json:"_id"`
-	ImportDate  ImportDate  `json:"_importdate"`
-	District    string      `json:"行政區"`
-	Neighborhood string     `json:"里別"`
-	Squad       string      `json:"分隊"`
-	StationCode string      `json:"局編"`
-	VehicleNumber string    `json:"車號"`
-	Route       string      `json:"路線"`
-	VehicleTrip string      `json:"車次"`
-	ArrivalTime string      `json:"抵達時間"`
-	DepartureTime string    `json:"離開時間"`
-	Location    string      `json:"地點"`
-	Longitude   string      `json:"經度"`
-	Latitude    string      `json:"緯度"`
+	ID            string     `json:"id"`
+	ImportDate    ImportDate `json:"import_date"`
+	City          string     `json:"city"`
+	District      string     `json:"district"`
+	Neighborhood  string     `json:"neighborhood"`
+	Squad         string     `json:"squad"`
+	StationCode   string     `json:"station_code"`
+	VehicleNumber string     `json:"vehicle_number"`
+	Route         string     `json:"route"`
+	VehicleTrip   string     `json:"vehicle_trip"`
+	ArrivalTime   string     `json:"arrival_time"`
+	DepartureTime string     `json:"departure_time"`
+	Location      string     `json:"location"`
+	Longitude     string     `json:"longitude"`
+	Latitude      string     `json:"latitude"`
+
+	// ServiceDays is set by providers whose source lists collection weekdays
+	ServiceDays Weekdays `json:"service_days,omitempty"`
+	// DayOffset is set by BuildRoutes: 1 for a stop reached after midnight on a
+	// trip that started the day before, whose weekday rules follow that day
+	DayOffset int `json:"day_offset,omitempty"`
 }
 
 type ImportDate struct {
@@ -53,170 +98,304 @@ type ImportDate struct {
 	Timezone     string `json:"timezone"`
 }
 
+// Route is one vehicle trip with its stops ordered by arrival time.
 type Route struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Stops []Stop `json:"stops"`
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	City          string `json:"city"`
+	VehicleNumber string `json:"vehicle_number"`
+	Trip          string `json:"trip"`
+	Stops         []Stop `json:"stops"`
 }
 
 type Stop struct {
-	Name string  `json:"name"`
-	Lat  float64 `json:"lat"`
-	Lng  float64 `json:"lng"`
-	Time string  `json:"time"`
+	Name          string  `json:"name"`
+	Lat           float64 `json:"lat"`
+	Lng           float64 `json:"lng"`
+	Time          string  `json:"time"`
+	DepartureTime string  `json:"departure_time"`
+	Sequence      int     `json:"sequence"`
+
+	// Point is the collection point the stop was built from
+	Point *CollectionPoint `json:"-"`
 }
 
 type NearestStop struct {
-	Stop     Stop
-	Route    Route
-	Distance float64
-	ETA      time.Time
+	Stop            Stop
+	Route           Route
+	Distance        float64
+	ETA             time.Time
 	CollectionPoint *CollectionPoint
+	// Notice explains a holiday suspension that delayed the ETA, if any
+	Notice string
+
+	// Departure is when the truck leaves the stop on the ETA visit, or on the
+	// visit it just left when Status is StopStatusJustLeft
+	Departure time.Time
+	Status    StopStatus
+	// NextStop is the following stop on the same trip when the truck just left
+	NextStop *Stop
+
+	// StopID identifies the location; Trips is how many trips serve it
+	StopID string
+	Trips  int
 }
 
-func NewGarbageAdapter() *GarbageAdapter {
+// NewGarbageAdapter creates an adapter over the given city providers.
+// Without providers it falls back to the Taipei dataset.
+func NewGarbageAdapter(providers ...Provider) *GarbageAdapter {
+	if len(providers) == 0 {
+		providers = []Provider{NewTaipeiProvider(DefaultTaipeiSource)}
+	}
+
 	return &GarbageAdapter{
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		providers: providers,
+		calendar:  NewCollectionCalendar(),
 	}
 }
 
+// SetCalendar replaces the collection calendar used to compute arrivals.
+func (ga *GarbageAdapter) SetCalendar(calendar *CollectionCalendar) {
+	ga.calendar = calendar
+}
+
+// Calendar returns the collection calendar used to compute arrivals.
+func (ga *GarbageAdapter) Calendar() *CollectionCalendar {
+	return ga.calendar
+}
+
+// Providers returns the configured city providers.
+func (ga *GarbageAdapter) Providers() []Provider {
+	return ga.providers
+}
+
+// FetchGarbageData loads every provider and merges the results into one dataset.
+// A provider that fails keeps its points from the previous snapshot, if any.
 func (ga *GarbageAdapter) FetchGarbageData(ctx context.Context) (*GarbageData, error) {
-	url := "https://raw.githubusercontent.com/Yukaii/garbage/data/trash-collection-points.json"
-	
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	
-	resp, err := ga.httpClient.Do(req)
-	if err != nil {
-		return nil, err
+	ga.mu.RLock()
+	previous := ga.data
+	ga.mu.RUnlock()
+
+	var points []CollectionPoint
+	var failed, failedCities []string
+
+	for _, provider := range ga.providers {
+		providerPoints, err := provider.Fetch(ctx, ga.httpClient)
+		if err != nil {
+			log.Printf("Error fetching %s collection data: %v", provider.Name(), err)
+			failed = append(failed, provider.Name())
+			failedCities = append(failedCities, provider.City())
+			if previous != nil {
+				points = append(points, previous.pointsInCity(provider.City())...)
+			}
+			continue
+		}
+
+		log.Printf("Fetched %d collection points from provider %s", len(providerPoints), provider.Name())
+		points = append(points, providerPoints...)
 	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+
+	if len(failed) == len(ga.providers) {
+		return nil, fmt.Errorf("all providers failed: %s", strings.Join(failed, ", "))
 	}
-	
-	var data GarbageData
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, err
+
+	return &GarbageData{
+		Result: GarbageResult{
+			Count:   len(points),
+			Results: points,
+		},
+		FailedCities: failedCities,
+	}, nil
+}
+
+// Index returns the spatial index over the dataset, building it on first use.
+func (d *GarbageData) Index() *SpatialIndex {
+	d.indexOnce.Do(func() {
+		d.index = NewSpatialIndex(d.Result.Results)
+	})
+	return d.index
+}
+
+// Routes returns every vehicle trip keyed by RouteKey, building them on first use.
+func (d *GarbageData) Routes() map[string]*Route {
+	d.routesOnce.Do(func() {
+		d.routes = BuildRoutes(d.Result.Results)
+	})
+	return d.routes
+}
+
+// RouteFor returns the full route the collection point belongs to.
+func (d *GarbageData) RouteFor(point *CollectionPoint) *Route {
+	return d.Routes()[RouteKey(point.City, point.VehicleNumber, point.VehicleTrip)]
+}
+
+func (d *GarbageData) pointsInCity(city string) []CollectionPoint {
+	var points []CollectionPoint
+	for _, point := range d.Result.Results {
+		if point.City == city {
+			points = append(points, point)
+		}
 	}
-	
-	return &data, nil
+	return points
 }
 
-func (ga *GarbageAdapter) FindNearestStops(userLat, userLng float64, data *GarbageData, limit int) ([]*NearestStop, error) {
-	var nearestStops []*NearestStop
-	now := utils.NowInTaiwan()
-	
-	for _, point := range data.Result.Results {
-		lat, lng, err := ga.parseCoordinates(point.Latitude, point.Longitude)
-		if err != nil {
-			continue
+// citiesFor returns the cities whose providers cover the coordinates.
+// It returns nil when no provider covers them, meaning every city is searched.
+func (ga *GarbageAdapter) citiesFor(lat, lng float64) map[string]bool {
+	var cities map[string]bool
+	for _, provider := range ga.providers {
+		if provider.Bounds().Contains(lat, lng) {
+			if cities == nil {
+				cities = make(map[string]bool)
+			}
+			cities[provider.City()] = true
 		}
-		
-		distance := geo.CalculateDistance(userLat, userLng, lat, lng)
-		
-		eta, err := parseTimeToToday(point.ArrivalTime)
+	}
+	return cities
+}
+
+// FindNearestStops returns up to limit stops closest to the user, with ETAs
+// computed as of now.
+func (ga *GarbageAdapter) FindNearestStops(userLat, userLng float64, data *GarbageData, limit int, now time.Time) ([]*NearestStop, error) {
+	accept := ga.acceptFor(userLat, userLng, data)
+
+	var matches []IndexMatch
+	if limit > 0 {
+		matches = data.Index().Nearest(userLat, userLng, limit*nearestOverfetch, accept)
+	} else {
+		matches = data.Index().All(userLat, userLng, accept)
+	}
+
+	nearestStops := make([]*NearestStop, 0, len(matches))
+	for _, match := range matches {
+		nearestStop, err := ga.newNearestStop(data, &data.Result.Results[match.Index], match, now)
 		if err != nil {
 			continue
 		}
-		
-		// 使用台灣時區比較時間
-		if eta.Before(now) {
-			eta = eta.Add(24 * time.Hour)
-		}
-		
-		stop := Stop{
-			Name: point.Location,
-			Lat:  lat,
-			Lng:  lng,
-			Time: point.ArrivalTime,
-		}
-		
-		route := Route{
-			ID:   point.VehicleNumber,
-			Name: point.Route,
-		}
-		
-		nearestStops = append(nearestStops, &NearestStop{
-			Stop:            stop,
-			Route:           route,
-			Distance:        distance,
-			ETA:             eta,
-			CollectionPoint: &point,
-		})
+		nearestStops = append(nearestStops, nearestStop)
 	}
-	
-	sort.Slice(nearestStops, func(i, j int) bool {
-		return nearestStops[i].Distance < nearestStops[j].Distance
+
+	// 同一地點有多個車次時，只保留最快抵達的一班
+	sort.SliceStable(nearestStops, func(i, j int) bool {
+		if nearestStops[i].Distance != nearestStops[j].Distance {
+			return nearestStops[i].Distance < nearestStops[j].Distance
+		}
+		return nearestStops[i].ETA.Before(nearestStops[j].ETA)
 	})
-	
+	nearestStops = collapseByStop(data, nearestStops)
 	if limit > 0 && len(nearestStops) > limit {
 		nearestStops = nearestStops[:limit]
 	}
-	
+
 	return nearestStops, nil
 }
 
-func (ga *GarbageAdapter) FindStopsInTimeWindow(userLat, userLng float64, data *GarbageData, timeWindow TimeWindow, maxDistance float64) ([]*NearestStop, error) {
+// FindStopsInTimeWindow returns the stops within maxDistance whose visit falls
+// in the time window, soonest first, with ETAs computed as of now.
+func (ga *GarbageAdapter) FindStopsInTimeWindow(userLat, userLng float64, data *GarbageData, timeWindow TimeWindow, maxDistance float64, now time.Time) ([]*NearestStop, error) {
+	accept := ga.acceptFor(userLat, userLng, data)
+
+	var matches []IndexMatch
+	if maxDistance > 0 {
+		matches = data.Index().Within(userLat, userLng, maxDistance, accept)
+	} else {
+		matches = data.Index().All(userLat, userLng, accept)
+	}
+
 	var validStops []*NearestStop
-	now := utils.NowInTaiwan()
-	
-	for _, point := range data.Result.Results {
-		lat, lng, err := ga.parseCoordinates(point.Latitude, point.Longitude)
+	for _, match := range matches {
+		nearestStop, err := ga.newNearestStop(data, &data.Result.Results[match.Index], match, now)
 		if err != nil {
 			continue
 		}
-		
-		distance := geo.CalculateDistance(userLat, userLng, lat, lng)
-		
-		if maxDistance > 0 && distance > maxDistance {
-			continue
-		}
-		
-		eta, err := parseTimeToToday(point.ArrivalTime)
-		if err != nil {
+
+		if !ga.inTimeWindow(nearestStop, timeWindow) {
 			continue
 		}
-		
-		if eta.Before(now) {
-			eta = eta.Add(24 * time.Hour)
+
+		validStops = append(validStops, nearestStop)
+	}
+
+	sort.Slice(validStops, func(i, j int) bool {
+		return validStops[i].ETA.Before(validStops[j].ETA)
+	})
+
+	return collapseByStop(data, validStops), nil
+}
+
+// acceptFor filters index candidates to the cities serving the coordinates and
+// to points with a usable arrival time.
+func (ga *GarbageAdapter) acceptFor(userLat, userLng float64, data *GarbageData) func(int) bool {
+	cities := ga.citiesFor(userLat, userLng)
+	return func(i int) bool {
+		point := &data.Result.Results[i]
+		if cities != nil && !cities[point.City] {
+			return false
 		}
-		
-		if !isTimeInWindow(eta, timeWindow) {
-			continue
+		_, err := clockMinutes(point.ArrivalTime)
+		return err == nil
+	}
+}
+
+func (ga *GarbageAdapter) newNearestStop(data *GarbageData, point *CollectionPoint, match IndexMatch, now time.Time) (*NearestStop, error) {
+	// 依收運日曆找出目前這一趟（在站中或剛離開）或下一個實際有收運的時段
+	eta, departure, status, err := ga.calendar.stopWindow(point, now)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := Stop{
+		Name:          point.Location,
+		Lat:           match.Lat,
+		Lng:           match.Lng,
+		Time:          point.ArrivalTime,
+		DepartureTime: point.DepartureTime,
+		Point:         point,
+	}
+
+	route := Route{
+		ID:            RouteKey(point.City, point.VehicleNumber, point.VehicleTrip),
+		Name:          point.Route,
+		VehicleNumber: point.VehicleNumber,
+		Trip:          point.VehicleTrip,
+	}
+	var nextStop *Stop
+	if fullRoute := data.RouteFor(point); fullRoute != nil {
+		route = *fullRoute
+		if i := fullRoute.StopIndex(point.Location); i >= 0 {
+			stop.Sequence = fullRoute.Stops[i].Sequence
 		}
-		
-		stop := Stop{
-			Name: point.Location,
-			Lat:  lat,
-			Lng:  lng,
-			Time: point.ArrivalTime,
+		if status == StopStatusJustLeft {
+			_, nextStop = fullRoute.Neighbors(point.Location)
 		}
-		
-		route := Route{
-			ID:   point.VehicleNumber,
-			Name: point.Route,
+	}
+
+	// 剛離開的話，ETA 改為下一個收運日的抵達時間
+	if status == StopStatusJustLeft {
+		eta, err = ga.calendar.NextArrival(point, now)
+		if err != nil {
+			return nil, err
 		}
-		
-		validStops = append(validStops, &NearestStop{
-			Stop:            stop,
-			Route:           route,
-			Distance:        distance,
-			ETA:             eta,
-			CollectionPoint: &point,
-		})
 	}
-	
-	sort.Slice(validStops, func(i, j int) bool {
-		return validStops[i].ETA.Before(validStops[j].ETA)
-	})
-	
-	return validStops, nil
+
+	notice := ga.suspensionNotice(point, now, eta)
+
+	return &NearestStop{
+		Stop:            stop,
+		Route:           route,
+		Distance:        match.Distance,
+		ETA:             eta,
+		CollectionPoint: point,
+		Notice:          notice,
+		Departure:       departure,
+		Status:          status,
+		NextStop:        nextStop,
+		StopID:          StopID(point),
+		Trips:           1,
+	}, nil
 }
 
 type TimeWindow struct {
@@ -224,26 +403,40 @@ type TimeWindow struct {
 	To   time.Time
 }
 
-func parseTimeToToday(timeStr string) (time.Time, error) {
-	taipeiTZ := utils.GetTaiwanTimezone()
-	now := utils.NowInTaiwan()
-	
-	if len(timeStr) == 4 {
-		layout := "1504"
-		t, err := time.Parse(layout, timeStr)
+// suspensionNotice describes the suspended day, if any, that pushed the arrival
+// after from.
+func (ga *GarbageAdapter) suspensionNotice(point *CollectionPoint, from, eta time.Time) string {
+	if special, ok := ga.calendar.SuspensionBefore(point, from, eta); ok {
+		return fmt.Sprintf("%s %s停收", special.Date[5:], special.Note)
+	}
+	return ""
+}
+
+// inTimeWindow reports whether the truck is at the stop during the window. When
+// the window starts after the current visit, e.g. 明天早上 or 下週一, the stop
+// is moved to its first visit in the window.
+func (ga *GarbageAdapter) inTimeWindow(stop *NearestStop, window TimeWindow) bool {
+	// 剛離開的站點 ETA 已是下一次抵達時間
+	leaves := stop.Departure
+	if stop.Status == StopStatusJustLeft {
+		leaves = stop.ETA
+	}
+
+	if !window.From.IsZero() && leaves.Before(window.From) {
+		dwell := dwellTime(stop.CollectionPoint)
+		eta, err := ga.calendar.NextArrival(stop.CollectionPoint, window.From.Add(-dwell))
 		if err != nil {
-			return time.Time{}, err
+			return false
 		}
-		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, taipeiTZ), nil
+		stop.ETA = eta
+		stop.Departure = eta.Add(dwell)
+		stop.Status = StopStatusUpcoming
+		stop.NextStop = nil
+		stop.Notice = ga.suspensionNotice(stop.CollectionPoint, window.From, eta)
+		return isTimeInWindow(eta, TimeWindow{To: window.To})
 	}
-	
-	layout := "15:04"
-	t, err := time.Parse(layout, timeStr)
-	if err != nil {
-		return time.Time{}, err
-	}
-	
-	return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, taipeiTZ), nil
+
+	return isTimeInWindow(stop.ETA, TimeWindow{To: window.To})
 }
 
 func isTimeInWindow(t time.Time, window TimeWindow) bool {
@@ -262,7 +455,7 @@ func isTimeInWindow(t time.Time, window TimeWindow) bool {
 	return true
 }
 
-func (ga *GarbageAdapter) parseCoordinates(lat, lng string) (float64, float64, error) {
+func parseCoordinates(lat, lng string) (float64, float64, error) {
 	latFloat, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
 	if err != nil {
 		return 0, 0, fmt.Errorf("invalid latitude: %s", lat)
@@ -276,29 +469,54 @@ func (ga *GarbageAdapter) parseCoordinates(lat, lng string) (float64, float64, e
 	return latFloat, lngFloat, nil
 }
 
+// GetRouteByID returns the route with the given RouteKey. A TripKey without the
+// city or a bare vehicle number is also accepted and resolves to the earliest
+// matching trip.
 func (ga *GarbageAdapter) GetRouteByID(data *GarbageData, routeID string) *Route {
-	for _, point := range data.Result.Results {
-		if point.VehicleNumber == routeID {
-			lat, lng, err := ga.parseCoordinates(point.Latitude, point.Longitude)
-			if err != nil {
-				continue
-			}
-			
-			stop := Stop{
-				Name: point.Location,
-				Lat:  lat,
-				Lng:  lng,
-				Time: point.ArrivalTime,
-			}
-			
-			return &Route{
-				ID:    point.VehicleNumber,
-				Name:  point.Route,
-				Stops: []Stop{stop},
-			}
+	routes := data.Routes()
+	if route, ok := routes[routeID]; ok {
+		return route
+	}
+
+	var earliest *Route
+	for _, route := range routes {
+		if route.VehicleNumber != routeID && TripKey(route.VehicleNumber, route.Trip) != routeID {
+			continue
+		}
+		if len(route.Stops) == 0 {
+			continue
+		}
+		if earliest == nil || route.Stops[0].Time < earliest.Stops[0].Time {
+			earliest = route
 		}
 	}
-	return nil
+	return earliest
+}
+
+// CollectionPointForStop returns the collection point of a route at the named stop.
+func (ga *GarbageAdapter) CollectionPointForStop(data *GarbageData, routeID, stopName string) (*CollectionPoint, error) {
+	route := ga.GetRouteByID(data, routeID)
+	if route == nil {
+		return nil, fmt.Errorf("route not found: %s", routeID)
+	}
+
+	i := route.StopIndex(stopName)
+	if i < 0 {
+		return nil, fmt.Errorf("stop %s not found on route %s", stopName, routeID)
+	}
+
+	return route.Stops[i].Point, nil
+}
+
+// NextArrivalForStop returns the next service-day arrival of a route at the named
+// stop, so reminders are only scheduled for days the truck actually runs.
+func (ga *GarbageAdapter) NextArrivalForStop(data *GarbageData, routeID, stopName string, from time.Time) (time.Time, error) {
+	point, err := ga.CollectionPointForStop(data, routeID, stopName)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return ga.calendar.NextArrival(point, from)
 }
 
 func (ga *GarbageAdapter) GetStopFromRoute(route *Route, stopName string) *Stop {
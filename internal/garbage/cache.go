@@ -0,0 +1,149 @@
+package garbage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// RefreshHook is called with every newly published snapshot.
+type RefreshHook func(ctx context.Context, data *GarbageData)
+
+// OnRefresh registers a hook that runs after each successful refresh. Hooks run
+// in registration order on their own goroutine, so a refresh triggered by a user
+// request does not wait for them, and are not cancelled with that request.
+// Snapshots are handed to hooks in order; one that is superseded before its
+// hooks start is skipped.
+func (ga *GarbageAdapter) OnRefresh(hook RefreshHook) {
+	ga.refreshMu.Lock()
+	defer ga.refreshMu.Unlock()
+	ga.refreshHooks = append(ga.refreshHooks, hook)
+}
+
+// GetGarbageData returns the cached dataset snapshot, downloading it on first use.
+func (ga *GarbageAdapter) GetGarbageData(ctx context.Context) (*GarbageData, error) {
+	ga.mu.RLock()
+	data := ga.data
+	ga.mu.RUnlock()
+
+	if data != nil {
+		return data, nil
+	}
+
+	if err := ga.refreshIfEmpty(ctx); err != nil {
+		return nil, err
+	}
+
+	ga.mu.RLock()
+	defer ga.mu.RUnlock()
+	return ga.data, nil
+}
+
+// Refresh downloads the dataset and replaces the cached snapshot.
+// The previous snapshot keeps being served if the download fails.
+func (ga *GarbageAdapter) Refresh(ctx context.Context) error {
+	ga.refreshMu.Lock()
+	defer ga.refreshMu.Unlock()
+
+	return ga.refreshLocked(ctx)
+}
+
+// LastLoaded reports when the cached snapshot was last replaced.
+// It returns the zero time if no snapshot has been loaded yet.
+func (ga *GarbageAdapter) LastLoaded() time.Time {
+	ga.mu.RLock()
+	defer ga.mu.RUnlock()
+	return ga.lastLoaded
+}
+
+// HealthReport returns the validation report of the cached snapshot, or nil if
+// no snapshot has been loaded yet.
+func (ga *GarbageAdapter) HealthReport() *HealthReport {
+	ga.mu.RLock()
+	defer ga.mu.RUnlock()
+	return ga.health
+}
+
+// StartRefresher loads the dataset immediately and then refreshes it every interval
+// until ctx is cancelled.
+func (ga *GarbageAdapter) StartRefresher(ctx context.Context, interval time.Duration) {
+	if err := ga.Refresh(ctx); err != nil {
+		log.Printf("Initial garbage data load failed: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Garbage data refresher started, interval=%s", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Garbage data refresher stopped")
+			return
+		case <-ticker.C:
+			if err := ga.Refresh(ctx); err != nil {
+				log.Printf("Error refreshing garbage data: %v", err)
+			}
+		}
+	}
+}
+
+func (ga *GarbageAdapter) refreshIfEmpty(ctx context.Context) error {
+	ga.refreshMu.Lock()
+	defer ga.refreshMu.Unlock()
+
+	ga.mu.RLock()
+	loaded := ga.data != nil
+	ga.mu.RUnlock()
+
+	// Another caller may have loaded the snapshot while we were waiting
+	if loaded {
+		return nil
+	}
+
+	return ga.refreshLocked(ctx)
+}
+
+func (ga *GarbageAdapter) refreshLocked(ctx context.Context) error {
+	start := time.Now()
+
+	data, err := ga.FetchGarbageData(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch garbage data: %w", err)
+	}
+
+	// Build the index before publishing so the first query does not pay for it.
+	// Routes are built too, since they set the DayOffset of overnight stops
+	index := data.Index()
+	data.Routes()
+	health := ValidateDataset(data.Result.Results)
+
+	ga.mu.Lock()
+	ga.data = data
+	ga.lastLoaded = time.Now()
+	ga.health = health
+	ga.mu.Unlock()
+
+	log.Printf("Garbage data refreshed: %d collection points (%d indexed, %d flagged) in %s",
+		len(data.Result.Results), index.Len(), health.FlaggedRows, time.Since(start))
+
+	ga.generation++
+	go ga.runRefreshHooks(context.WithoutCancel(ctx), ga.refreshHooks, data, ga.generation)
+	return nil
+}
+
+func (ga *GarbageAdapter) runRefreshHooks(ctx context.Context, hooks []RefreshHook, data *GarbageData, generation int) {
+	ga.hooksMu.Lock()
+	defer ga.hooksMu.Unlock()
+
+	if generation <= ga.hookedGeneration {
+		return
+	}
+	ga.hookedGeneration = generation
+
+	for _, hook := range hooks {
+		hook(ctx, data)
+	}
+}
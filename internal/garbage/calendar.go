@@ -0,0 +1,214 @@
+package garbage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/utils"
+)
+
+// Weekdays is a set of days of the week, one bit per time.Weekday.
+// The zero value means "not specified".
+type Weekdays uint8
+
+// EveryDay is the set of all seven days.
+const EveryDay Weekdays = 1<<7 - 1
+
+// maxCalendarLookahead bounds how far NextArrival searches for a service day.
+const maxCalendarLookahead = 14
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+var weekdayChinese = []string{"日", "一", "二", "三", "四", "五", "六"}
+
+func NewWeekdays(days ...time.Weekday) Weekdays {
+	var w Weekdays
+	for _, d := range days {
+		w |= 1 << uint(d)
+	}
+	return w
+}
+
+func (w Weekdays) Has(day time.Weekday) bool {
+	return w&(1<<uint(day)) != 0
+}
+
+// String renders the set in Chinese, e.g. "一二四五六".
+func (w Weekdays) String() string {
+	var sb strings.Builder
+	for _, d := range []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday} {
+		if w.Has(d) {
+			sb.WriteString(weekdayChinese[d])
+		}
+	}
+	return sb.String()
+}
+
+// MarshalJSON renders the set as a list of English day abbreviations.
+func (w Weekdays) MarshalJSON() ([]byte, error) {
+	names := []string{}
+	for name, day := range weekdayNames {
+		if w.Has(day) {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return weekdayNames[names[i]] < weekdayNames[names[j]]
+	})
+	return json.Marshal(names)
+}
+
+// UnmarshalJSON accepts a list of English day abbreviations such as ["mon","tue"].
+func (w *Weekdays) UnmarshalJSON(b []byte) error {
+	var names []string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return err
+	}
+
+	*w = 0
+	for _, name := range names {
+		day, ok := weekdayNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return fmt.Errorf("invalid weekday: %s", name)
+		}
+		*w |= NewWeekdays(day)
+	}
+	return nil
+}
+
+// CollectionCalendar decides on which days a collection point is served.
+// Rules are resolved from the most to the least specific: the point's own
+// ServiceDays, then its route, then its city, and finally every day.
+type CollectionCalendar struct {
+	cityDays  map[string]Weekdays
+	routeDays map[string]Weekdays
+
+	// specialDays holds holiday overrides per city, keyed by "2006-01-02". A
+	// date may have a city-wide entry and entries limited to some routes.
+	specialDays map[string]map[string][]SpecialDay
+}
+
+type calendarRules struct {
+	Cities map[string]Weekdays `json:"cities"`
+	Routes map[string]Weekdays `json:"routes"`
+}
+
+// NewCollectionCalendar returns a calendar with the default city rule for
+// Taipei, where general waste is not collected on Wednesdays and Sundays. Other
+// cities rely on the ServiceDays of their providers and otherwise run every day.
+func NewCollectionCalendar() *CollectionCalendar {
+	return &CollectionCalendar{
+		cityDays: map[string]Weekdays{
+			"台北市": NewWeekdays(time.Monday, time.Tuesday, time.Thursday, time.Friday, time.Saturday),
+		},
+		routeDays:   make(map[string]Weekdays),
+		specialDays: make(map[string]map[string][]SpecialDay),
+	}
+}
+
+// LoadCalendarRules overrides city and route rules from a JSON file such as
+// {"cities": {"台北市": ["mon","tue"]}, "routes": {"台北市:KES-1021_第1車": ["fri"]}}.
+func (c *CollectionCalendar) LoadCalendarRules(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var rules calendarRules
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return fmt.Errorf("invalid calendar rules %s: %w", path, err)
+	}
+
+	for city, days := range rules.Cities {
+		c.SetCityDays(city, days)
+	}
+	for routeKey, days := range rules.Routes {
+		c.SetRouteDays(routeKey, days)
+	}
+	return nil
+}
+
+func (c *CollectionCalendar) SetCityDays(city string, days Weekdays) {
+	c.cityDays[city] = days
+}
+
+// SetRouteDays sets the rule for one trip, keyed by RouteKey.
+func (c *CollectionCalendar) SetRouteDays(routeKey string, days Weekdays) {
+	c.routeDays[routeKey] = days
+}
+
+// ServiceDays returns the weekdays on which the point is served.
+func (c *CollectionCalendar) ServiceDays(point *CollectionPoint) Weekdays {
+	if point.ServiceDays != 0 {
+		return point.ServiceDays
+	}
+	if days, ok := c.routeDays[RouteKey(point.City, point.VehicleNumber, point.VehicleTrip)]; ok {
+		return days
+	}
+	if days, ok := c.cityDays[point.City]; ok {
+		return days
+	}
+	return EveryDay
+}
+
+// RouteServiceDays returns the weekdays on which the trip runs: the route's own
+// rule if one is set, otherwise the days of its stops. Stops that disagree are
+// merged, since a trip runs whenever any of its stops is served.
+func (c *CollectionCalendar) RouteServiceDays(route *Route) Weekdays {
+	if days, ok := c.routeDays[route.ID]; ok {
+		return days
+	}
+
+	var days Weekdays
+	for _, stop := range route.Stops {
+		if stop.Point != nil {
+			days |= c.ServiceDays(stop.Point)
+		}
+	}
+	if days == 0 {
+		if cityDays, ok := c.cityDays[route.City]; ok {
+			return cityDays
+		}
+		return EveryDay
+	}
+	return days
+}
+
+// IsServiceDay reports whether the point is served on the date of t in Taiwan time.
+// Holiday overrides take precedence over the weekday rules.
+func (c *CollectionCalendar) IsServiceDay(point *CollectionPoint, t time.Time) bool {
+	if special, ok := c.SpecialDayFor(point, t); ok {
+		return special.Type == SpecialDayExtra
+	}
+	return c.ServiceDays(point).Has(utils.ToTaiwan(t).Weekday())
+}
+
+// NextArrival returns the first arrival at or after from that falls on a service
+// day. A stop reached after midnight is checked against the day its trip started.
+func (c *CollectionCalendar) NextArrival(point *CollectionPoint, from time.Time) (time.Time, error) {
+	minutes, err := clockMinutes(point.ArrivalTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	from = utils.ToTaiwan(from)
+	for d := 0; d <= maxCalendarLookahead; d++ {
+		day := from.AddDate(0, 0, d)
+		arrival := time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, from.Location())
+		if arrival.Before(from) {
+			continue
+		}
+		if c.IsServiceDay(point, arrival.AddDate(0, 0, -point.DayOffset)) {
+			return arrival, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("no service day within %d days for %s", maxCalendarLookahead, point.Location)
+}
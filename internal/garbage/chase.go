@@ -0,0 +1,103 @@
+package garbage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"linebot-garbage-helper/internal/geo"
+)
+
+const (
+	// chaseMaxWalk is the farthest downstream stop suggested when chasing a truck, in meters.
+	chaseMaxWalk = 1500.0
+	// chaseHorizon bounds how far ahead of now a downstream arrival may be.
+	chaseHorizon = time.Hour
+)
+
+// ChaseStop is a downstream stop of a missed truck that the user can still
+// reach on foot before the truck leaves it.
+type ChaseStop struct {
+	Stop      Stop
+	Arrival   time.Time
+	Departure time.Time
+	// Distance is the walking distance from the user in meters
+	Distance float64
+	WalkTime time.Duration
+	// Spare is how long the user would wait at the stop for the truck; zero
+	// when the truck is already there on arrival
+	Spare time.Duration
+}
+
+// ChaseTruck finds the stops after stopName on the route, on the visit that
+// just ended or is in progress, that the user at userLat/userLng can walk to
+// before the truck leaves them. Stops are ordered by walking time.
+func (ga *GarbageAdapter) ChaseTruck(data *GarbageData, routeID, stopName string, userLat, userLng float64, now time.Time) ([]*ChaseStop, error) {
+	route := ga.GetRouteByID(data, routeID)
+	if route == nil {
+		return nil, fmt.Errorf("route not found: %s", routeID)
+	}
+
+	missed := route.StopIndex(stopName)
+	if missed < 0 {
+		return nil, fmt.Errorf("stop %s not found on route %s", stopName, routeID)
+	}
+
+	// The visit that matters is the one just left or in progress. Stops are
+	// dated from the day the trip started, plus a day for every time the clock
+	// goes backwards along the route, so stops after midnight fall on the next day
+	missedArrival, _, _, err := ga.calendar.stopWindow(route.Stops[missed].Point, now)
+	if err != nil {
+		return nil, err
+	}
+	offsets := dayOffsets(route)
+	start := missedArrival.AddDate(0, 0, -offsets[missed])
+
+	var stops []*ChaseStop
+	for i := missed + 1; i < len(route.Stops); i++ {
+		stop := route.Stops[i]
+		minutes, err := clockMinutes(stop.Time)
+		if err != nil {
+			continue
+		}
+
+		day := start.AddDate(0, 0, offsets[i])
+		arrival := time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+		leave := arrival.Add(dwellTime(stop.Point))
+		// Weekday rules follow the day the trip started, as in the GTFS export
+		if arrival.Sub(now) > chaseHorizon || !ga.calendar.IsServiceDay(stop.Point, start) {
+			continue
+		}
+
+		distance := geo.CalculateDistance(userLat, userLng, stop.Lat, stop.Lng)
+		if distance > chaseMaxWalk {
+			continue
+		}
+
+		walk := geo.WalkingTime(distance)
+		reachAt := now.Add(walk)
+		if reachAt.After(leave) {
+			continue
+		}
+
+		spare := arrival.Sub(reachAt)
+		if spare < 0 {
+			spare = 0
+		}
+
+		stops = append(stops, &ChaseStop{
+			Stop:      stop,
+			Arrival:   arrival,
+			Departure: leave,
+			Distance:  distance,
+			WalkTime:  walk,
+			Spare:     spare,
+		})
+	}
+
+	sort.SliceStable(stops, func(i, j int) bool {
+		return stops[i].WalkTime < stops[j].WalkTime
+	})
+
+	return stops, nil
+}
@@ -0,0 +1,115 @@
+package garbage
+
+import (
+	"sort"
+	"time"
+
+	"linebot-garbage-helper/internal/geo"
+)
+
+// defaultCommuteSlack is how long a commuter is assumed to be willing to wait at a stop.
+const defaultCommuteSlack = 10 * time.Minute
+
+// CorridorQuery describes a trip along a path during which the user wants to
+// drop off trash.
+type CorridorQuery struct {
+	Path []geo.Point
+	// Width is the maximum distance of a stop from the path, in meters
+	Width float64
+	// Depart is when the user leaves the start of the path
+	Depart time.Time
+	// Speed is the travel pace along the path in meters per minute;
+	// geo.WalkingSpeed if zero
+	Speed float64
+	// Slack is how long the user is willing to wait at a stop;
+	// defaultCommuteSlack if zero
+	Slack time.Duration
+}
+
+// CorridorStop is a stop near the path. Its NearestStop.Distance is the detour
+// from the path.
+type CorridorStop struct {
+	*NearestStop
+	// Along is how far along the path the stop is, in meters
+	Along float64
+	// PassAt is when the user reaches the stop
+	PassAt time.Time
+}
+
+// FindStopsAlongPath returns the stops within the corridor around the path
+// whose truck is at the stop, or arrives within the slack, when the user
+// passes by. Results are ordered along the path.
+func (ga *GarbageAdapter) FindStopsAlongPath(data *GarbageData, query CorridorQuery) ([]*CorridorStop, error) {
+	speed := query.Speed
+	if speed <= 0 {
+		speed = geo.WalkingSpeed
+	}
+	slack := query.Slack
+	if slack <= 0 {
+		slack = defaultCommuteSlack
+	}
+
+	accept := func(i int) bool {
+		_, err := clockMinutes(data.Result.Results[i].ArrivalTime)
+		return err == nil
+	}
+
+	// Query overlapping circles along the path; each circle covers the
+	// corridor half way to the next sample
+	candidates := make(map[int]IndexMatch)
+	for _, sample := range geo.SamplePath(query.Path, query.Width) {
+		for _, match := range data.Index().Within(sample.Lat, sample.Lng, query.Width*1.5, accept) {
+			candidates[match.Index] = match
+		}
+	}
+
+	var stops []*CorridorStop
+	for _, match := range candidates {
+		offset, along := geo.DistanceToPath(match.Lat, match.Lng, query.Path)
+		if offset > query.Width {
+			continue
+		}
+
+		// The detour to the stop is walked even when the path is travelled faster
+		travel := time.Duration(along/speed*float64(time.Minute)) + geo.WalkingTime(offset)
+		passAt := query.Depart.Add(travel)
+
+		match.Distance = offset
+		stop, err := ga.newNearestStop(data, &data.Result.Results[match.Index], match, passAt)
+		if err != nil {
+			continue
+		}
+
+		switch stop.Status {
+		case StopStatusAtStop:
+		case StopStatusUpcoming:
+			if stop.ETA.Sub(passAt) > slack {
+				continue
+			}
+		default:
+			continue
+		}
+
+		stops = append(stops, &CorridorStop{NearestStop: stop, Along: along, PassAt: passAt})
+	}
+
+	sort.Slice(stops, func(i, j int) bool {
+		if stops[i].Along != stops[j].Along {
+			return stops[i].Along < stops[j].Along
+		}
+		return stops[i].ETA.Before(stops[j].ETA)
+	})
+
+	// Keep one trip per location
+	seen := make(map[string]bool)
+	unique := stops[:0]
+	for _, stop := range stops {
+		if seen[stop.StopID] {
+			continue
+		}
+		seen[stop.StopID] = true
+		unique = append(unique, stop)
+	}
+
+	return unique, nil
+}
@@ -0,0 +1,91 @@
+package garbage
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// coverageCellSize is the coverage grid cell edge in degrees, roughly 2 km.
+	coverageCellSize = 0.02
+	// coverageMargin is how many cells around a cell with collection points
+	// still count as covered, so the edge of a city is not cut off.
+	coverageMargin = 1
+)
+
+// Coverage is the area each city's collection data actually serves, as a grid
+// of covered cells. Unlike provider bounds it follows the points themselves, so
+// mountains and sea inside a bounding box are not covered.
+type Coverage struct {
+	cells  map[gridCell]map[string]bool
+	cities []string
+}
+
+// BuildCoverage marks the cells around every point with parseable coordinates
+// as covered by the point's city.
+func BuildCoverage(points []CollectionPoint) *Coverage {
+	c := &Coverage{cells: make(map[gridCell]map[string]bool)}
+	seen := make(map[string]bool)
+
+	for i := range points {
+		lat, lng, err := parseCoordinates(points[i].Latitude, points[i].Longitude)
+		if err != nil {
+			continue
+		}
+
+		city := points[i].City
+		if !seen[city] {
+			seen[city] = true
+			c.cities = append(c.cities, city)
+		}
+
+		center := coverageCell(lat, lng)
+		for row := center.row - coverageMargin; row <= center.row+coverageMargin; row++ {
+			for col := center.col - coverageMargin; col <= center.col+coverageMargin; col++ {
+				cell := gridCell{row, col}
+				if c.cells[cell] == nil {
+					c.cells[cell] = make(map[string]bool)
+				}
+				c.cells[cell][city] = true
+			}
+		}
+	}
+
+	sort.Strings(c.cities)
+	return c
+}
+
+// Covers reports whether any city's data serves the coordinates.
+func (c *Coverage) Covers(lat, lng float64) bool {
+	return len(c.cells[coverageCell(lat, lng)]) > 0
+}
+
+// CitiesAt returns the cities whose data serves the coordinates.
+func (c *Coverage) CitiesAt(lat, lng float64) []string {
+	var cities []string
+	for city := range c.cells[coverageCell(lat, lng)] {
+		cities = append(cities, city)
+	}
+	sort.Strings(cities)
+	return cities
+}
+
+// Cities returns every city with collection data.
+func (c *Coverage) Cities() []string {
+	return c.cities
+}
+
+// Coverage returns the covered area, building it on first use.
+func (d *GarbageData) Coverage() *Coverage {
+	d.coverageOnce.Do(func() {
+		d.coverage = BuildCoverage(d.Result.Results)
+	})
+	return d.coverage
+}
+
+func coverageCell(lat, lng float64) gridCell {
+	return gridCell{
+		row: int(math.Floor(lat / coverageCellSize)),
+		col: int(math.Floor(lng / coverageCellSize)),
+	}
+}
@@ -0,0 +1,182 @@
+package garbage
+
+import (
+	"sort"
+)
+
+// StopChange is one stop that was added, removed or rescheduled between snapshots.
+type StopChange struct {
+	RouteID       string  `json:"route_id"`
+	RouteName     string  `json:"route_name"`
+	VehicleNumber string  `json:"vehicle_number"`
+	Location      string  `json:"location"`
+	Lat           float64 `json:"lat"`
+	Lng           float64 `json:"lng"`
+	OldTime       string  `json:"old_time,omitempty"`
+	NewTime       string  `json:"new_time,omitempty"`
+}
+
+// SnapshotDiff lists the stop-level differences between two snapshots.
+type SnapshotDiff struct {
+	Added   []StopChange `json:"added"`
+	Removed []StopChange `json:"removed"`
+	Changed []StopChange `json:"changed"`
+}
+
+// Empty reports whether the snapshots are equivalent.
+func (d *SnapshotDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// RouteIDs returns the IDs of every route touched by the diff.
+func (d *SnapshotDiff) RouteIDs() []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, changes := range [][]StopChange{d.Added, d.Removed, d.Changed} {
+		for _, change := range changes {
+			if !seen[change.RouteID] {
+				seen[change.RouteID] = true
+				ids = append(ids, change.RouteID)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// DiffRoutes compares two sets of routes keyed by RouteKey. Stops are matched
+// by location within a route; a matched stop with a different arrival time is
+// reported as changed.
+func DiffRoutes(previous, current map[string]*Route) *SnapshotDiff {
+	diff := &SnapshotDiff{}
+
+	for id, route := range current {
+		oldStops := stopsByLocation(previous[id])
+		for _, stop := range route.Stops {
+			old, ok := oldStops[stop.Name]
+			switch {
+			case !ok:
+				diff.Added = append(diff.Added, newStopChange(route, stop, "", stop.Time))
+			case old.Time != stop.Time:
+				diff.Changed = append(diff.Changed, newStopChange(route, stop, old.Time, stop.Time))
+			}
+		}
+	}
+
+	for id, route := range previous {
+		newStops := stopsByLocation(current[id])
+		for _, stop := range route.Stops {
+			if _, ok := newStops[stop.Name]; !ok {
+				diff.Removed = append(diff.Removed, newStopChange(route, stop, stop.Time, ""))
+			}
+		}
+	}
+
+	for _, changes := range [][]StopChange{diff.Added, diff.Removed, diff.Changed} {
+		sort.Slice(changes, func(i, j int) bool {
+			if changes[i].RouteID != changes[j].RouteID {
+				return changes[i].RouteID < changes[j].RouteID
+			}
+			return changes[i].Location < changes[j].Location
+		})
+	}
+
+	return diff
+}
+
+func stopsByLocation(route *Route) map[string]Stop {
+	stops := make(map[string]Stop)
+	if route == nil {
+		return stops
+	}
+	for _, stop := range route.Stops {
+		// Keep the first visit when a trip passes the same location twice
+		if _, ok := stops[stop.Name]; !ok {
+			stops[stop.Name] = stop
+		}
+	}
+	return stops
+}
+
+func newStopChange(route *Route, stop Stop, oldTime, newTime string) StopChange {
+	return StopChange{
+		RouteID:       route.ID,
+		RouteName:     route.Name,
+		VehicleNumber: route.VehicleNumber,
+		Location:      stop.Name,
+		Lat:           stop.Lat,
+		Lng:           stop.Lng,
+		OldTime:       oldTime,
+		NewTime:       newTime,
+	}
+}
+
+// RouteSnapshotData converts a route into the generic map stored by
+// FirestoreClient.StoreRouteData.
+func RouteSnapshotData(route *Route) map[string]interface{} {
+	stops := make([]interface{}, 0, len(route.Stops))
+	for _, stop := range route.Stops {
+		stops = append(stops, map[string]interface{}{
+			"name": stop.Name,
+			"time": stop.Time,
+			"lat":  stop.Lat,
+			"lng":  stop.Lng,
+		})
+	}
+
+	return map[string]interface{}{
+		"key":     route.ID,
+		"name":    route.Name,
+		"city":    route.City,
+		"vehicle": route.VehicleNumber,
+		"trip":    route.Trip,
+		"stops":   stops,
+	}
+}
+
+// RouteFromSnapshotData is the inverse of RouteSnapshotData. Snapshots stored
+// before RouteKey carried the city are re-keyed when their city is known.
+func RouteFromSnapshotData(data map[string]interface{}) *Route {
+	route := &Route{
+		ID:            stringValue(data["key"]),
+		Name:          stringValue(data["name"]),
+		City:          stringValue(data["city"]),
+		VehicleNumber: stringValue(data["vehicle"]),
+		Trip:          stringValue(data["trip"]),
+	}
+	if route.City != "" && route.VehicleNumber != "" {
+		route.ID = RouteKey(route.City, route.VehicleNumber, route.Trip)
+	}
+
+	stops, _ := data["stops"].([]interface{})
+	for i, s := range stops {
+		stop, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		route.Stops = append(route.Stops, Stop{
+			Name:     stringValue(stop["name"]),
+			Time:     stringValue(stop["time"]),
+			Lat:      floatValue(stop["lat"]),
+			Lng:      floatValue(stop["lng"]),
+			Sequence: i + 1,
+		})
+	}
+
+	return route
+}
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func floatValue(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int64:
+		return float64(n)
+	}
+	return 0
+}
@@ -0,0 +1,323 @@
+package garbage
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"linebot-garbage-helper/internal/geo"
+)
+
+// maxAreaMatches caps how many areas one gazetteer lookup returns.
+const maxAreaMatches = 10
+
+// Area levels, from broadest to narrowest.
+const (
+	areaLevelCity = iota
+	areaLevelDistrict
+	areaLevelNeighborhood
+)
+
+// areaSuffixes are the administrative suffixes that may be left off a name.
+var areaSuffixes = []string{"市", "縣", "區", "鄉", "鎮", "里", "村"}
+
+// gazetteerFillers may surround area names in a query without making it
+// anything more specific than an area.
+var gazetteerFillers = []string{"附近", "一帶", "的"}
+
+// Area is a district (行政區) or neighbourhood (里) with the collection points
+// serving it.
+type Area struct {
+	ID           string `json:"id"`
+	City         string `json:"city"`
+	District     string `json:"district"`
+	Neighborhood string `json:"neighborhood,omitempty"`
+	// Lat and Lng are the centroid of the points serving the area
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+
+	Points []*CollectionPoint `json:"-"`
+}
+
+// Name returns the full area name, e.g. 台北市大安區龍安里.
+func (a *Area) Name() string {
+	return a.City + a.District + a.Neighborhood
+}
+
+// Gazetteer resolves district and neighbourhood names to the areas in the
+// dataset without geocoding.
+type Gazetteer struct {
+	areas map[string]*Area
+	// names maps every normalized full name and short name to what it refers to
+	names map[string][]gazetteerName
+	// texts are the keys of names, longest first
+	texts []string
+}
+
+type gazetteerName struct {
+	level int
+	// key is the area ID of a district or neighbourhood, or the normalized city
+	key string
+}
+
+// BuildGazetteer indexes the districts and neighbourhoods of every point with
+// parseable coordinates.
+func BuildGazetteer(points []CollectionPoint) *Gazetteer {
+	g := &Gazetteer{
+		areas: make(map[string]*Area),
+		names: make(map[string][]gazetteerName),
+	}
+
+	for i := range points {
+		point := &points[i]
+		if point.District == "" {
+			continue
+		}
+		lat, lng, err := parseCoordinates(point.Latitude, point.Longitude)
+		if err != nil {
+			continue
+		}
+
+		g.addPoint(point, "", lat, lng)
+		if point.Neighborhood != "" {
+			g.addPoint(point, point.Neighborhood, lat, lng)
+		}
+	}
+
+	for _, area := range g.areas {
+		// Lat/Lng hold coordinate sums until every point is added
+		area.Lat /= float64(len(area.Points))
+		area.Lng /= float64(len(area.Points))
+	}
+
+	for text := range g.names {
+		g.texts = append(g.texts, text)
+	}
+	sort.Slice(g.texts, func(i, j int) bool {
+		a, b := utf8.RuneCountInString(g.texts[i]), utf8.RuneCountInString(g.texts[j])
+		if a != b {
+			return a > b
+		}
+		return g.texts[i] < g.texts[j]
+	})
+
+	return g
+}
+
+func (g *Gazetteer) addPoint(point *CollectionPoint, neighborhood string, lat, lng float64) {
+	id := AreaID(point.City, point.District, neighborhood)
+	area, ok := g.areas[id]
+	if !ok {
+		area = &Area{
+			ID:           id,
+			City:         point.City,
+			District:     point.District,
+			Neighborhood: neighborhood,
+		}
+		g.areas[id] = area
+
+		if neighborhood == "" {
+			g.addName(point.District, gazetteerName{level: areaLevelDistrict, key: id})
+			g.addName(point.City, gazetteerName{level: areaLevelCity, key: normalizeAreaName(point.City)})
+		} else {
+			g.addName(neighborhood, gazetteerName{level: areaLevelNeighborhood, key: id})
+		}
+	}
+
+	area.Points = append(area.Points, point)
+	area.Lat += lat
+	area.Lng += lng
+}
+
+// addName registers the full name and, when it is still distinctive, the name
+// without its administrative suffix (大安區 → 大安).
+func (g *Gazetteer) addName(name string, entry gazetteerName) {
+	full := normalizeAreaName(name)
+	if full == "" {
+		return
+	}
+
+	texts := []string{full}
+	if short := trimAreaSuffix(full); short != full && utf8.RuneCountInString(short) >= 2 {
+		texts = append(texts, short)
+	}
+
+	for _, text := range texts {
+		duplicate := false
+		for _, existing := range g.names[text] {
+			if existing == entry {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			g.names[text] = append(g.names[text], entry)
+		}
+	}
+}
+
+// Area returns the area with the given ID.
+func (g *Gazetteer) Area(id string) (*Area, bool) {
+	area, ok := g.areas[id]
+	return area, ok
+}
+
+// Lookup resolves a query made up only of city, district and neighbourhood
+// names, such as "大安區 龍安里" or "台北 大安", to the matching areas. Partial
+// names without their suffix and 台/臺 variants are accepted. It returns nil
+// when the query mentions anything else, e.g. a street, so the caller can fall
+// back to geocoding.
+func (g *Gazetteer) Lookup(query string) []*Area {
+	// 有路名、門牌的地址比行政區精確，交給地理編碼
+	if geo.ParseAddress(query).Road != "" {
+		return nil
+	}
+
+	rest := normalizeAreaName(query)
+	if rest == "" {
+		return nil
+	}
+
+	matched := make(map[int]map[string]bool)
+	for _, text := range g.texts {
+		if !strings.Contains(rest, text) {
+			continue
+		}
+		rest = strings.ReplaceAll(rest, text, " ")
+
+		// A short name shared by a district and a neighbourhood (信義) refers to
+		// the broader one
+		entries := g.names[text]
+		broadest := areaLevelNeighborhood
+		for _, entry := range entries {
+			broadest = min(broadest, entry.level)
+		}
+		for _, entry := range entries {
+			if entry.level != broadest {
+				continue
+			}
+			if matched[entry.level] == nil {
+				matched[entry.level] = make(map[string]bool)
+			}
+			matched[entry.level][entry.key] = true
+		}
+	}
+
+	for _, filler := range gazetteerFillers {
+		rest = strings.ReplaceAll(rest, filler, "")
+	}
+	if strings.TrimSpace(rest) != "" {
+		return nil
+	}
+
+	// A city alone is too broad to list stops for, so at least a district must match
+	level := areaLevelNeighborhood
+	if len(matched[level]) == 0 {
+		level = areaLevelDistrict
+	}
+
+	var areas []*Area
+	for id := range matched[level] {
+		area := g.areas[id]
+		if cities := matched[areaLevelCity]; len(cities) > 0 && !cities[normalizeAreaName(area.City)] {
+			continue
+		}
+		if level == areaLevelNeighborhood {
+			if districts := matched[areaLevelDistrict]; len(districts) > 0 && !districts[AreaID(area.City, area.District, "")] {
+				continue
+			}
+		}
+		areas = append(areas, area)
+	}
+
+	sort.Slice(areas, func(i, j int) bool {
+		return areas[i].ID < areas[j].ID
+	})
+	if len(areas) > maxAreaMatches {
+		areas = areas[:maxAreaMatches]
+	}
+	return areas
+}
+
+// Places returns the centroid of every area for offline geocoding, named both
+// with and without the city.
+func (g *Gazetteer) Places() []geo.Place {
+	places := make([]geo.Place, 0, len(g.areas)*2)
+	for _, area := range g.areas {
+		places = append(places,
+			geo.Place{Name: area.Name(), Lat: area.Lat, Lng: area.Lng},
+			geo.Place{Name: area.District + area.Neighborhood, Lat: area.Lat, Lng: area.Lng},
+		)
+	}
+
+	sort.Slice(places, func(i, j int) bool {
+		return places[i].Name < places[j].Name
+	})
+	return places
+}
+
+// Gazetteer returns the district and neighbourhood gazetteer, building it on first use.
+func (d *GarbageData) Gazetteer() *Gazetteer {
+	d.gazetteerOnce.Do(func() {
+		d.gazetteer = BuildGazetteer(d.Result.Results)
+	})
+	return d.gazetteer
+}
+
+// AreaID identifies a district, or a neighbourhood when one is given.
+func AreaID(city, district, neighborhood string) string {
+	id := normalizeAreaName(city) + "/" + normalizeAreaName(district)
+	if neighborhood != "" {
+		id += "/" + normalizeAreaName(neighborhood)
+	}
+	return id
+}
+
+// normalizeAreaName unifies 臺/台 and full-width characters and drops
+// whitespace and separators.
+func normalizeAreaName(name string) string {
+	return geo.NormalizeAddressText(name)
+}
+
+func trimAreaSuffix(name string) string {
+	for _, suffix := range areaSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix)
+		}
+	}
+	return name
+}
+
+// FindStopsInArea returns the stops serving the area within the time window,
+// soonest first, with ETAs computed as of now. Distances are measured from the
+// area centroid.
+func (ga *GarbageAdapter) FindStopsInArea(data *GarbageData, area *Area, timeWindow TimeWindow, now time.Time) ([]*NearestStop, error) {
+
+	var stops []*NearestStop
+	for _, point := range area.Points {
+		if _, err := clockMinutes(point.ArrivalTime); err != nil {
+			continue
+		}
+		lat, lng, err := parseCoordinates(point.Latitude, point.Longitude)
+		if err != nil {
+			continue
+		}
+
+		match := IndexMatch{Lat: lat, Lng: lng, Distance: geo.CalculateDistance(area.Lat, area.Lng, lat, lng)}
+		stop, err := ga.newNearestStop(data, point, match, now)
+		if err != nil {
+			continue
+		}
+		if !ga.inTimeWindow(stop, timeWindow) {
+			continue
+		}
+		stops = append(stops, stop)
+	}
+
+	sort.Slice(stops, func(i, j int) bool {
+		return stops[i].ETA.Before(stops[j].ETA)
+	})
+
+	return collapseByStop(data, stops), nil
+}
@@ -0,0 +1,257 @@
+package garbage
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/utils"
+)
+
+// gtfsRouteType is the GTFS route_type written for every route. Garbage trucks
+// have no dedicated type, so they are exported as buses.
+const gtfsRouteType = "3"
+
+// gtfsServiceYears is how long the exported calendar is valid for.
+const gtfsServiceYears = 1
+
+// exception_type values of calendar_dates.txt.
+const (
+	gtfsServiceAdded   = "1"
+	gtfsServiceRemoved = "2"
+)
+
+// gtfsWeekdays is the column order of calendar.txt.
+var gtfsWeekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// GTFSFeed is a GTFS-like representation of the collection schedule. Stops are
+// unique locations, routes are named collection lines, trips are vehicle trips
+// (one per RouteKey) and services are the weekday sets of a city from the
+// calendar, with its holiday overrides as calendar dates. Trips with their own
+// holiday overrides get a service of their own.
+type GTFSFeed struct {
+	Agencies      [][]string
+	Stops         [][]string
+	Routes        [][]string
+	Trips         [][]string
+	StopTimes     [][]string
+	Calendar      [][]string
+	CalendarDates [][]string
+}
+
+// gtfsService is one service_id: a weekday set and the point used to look up
+// the holiday overrides that apply to it.
+type gtfsService struct {
+	days  Weekdays
+	point *CollectionPoint
+}
+
+// BuildGTFSFeed converts the snapshot into a feed whose services start on the
+// date of start in Taiwan time.
+func BuildGTFSFeed(data *GarbageData, calendar *CollectionCalendar, start time.Time) *GTFSFeed {
+	feed := &GTFSFeed{
+		Agencies:      [][]string{{"agency_id", "agency_name", "agency_url", "agency_timezone", "agency_lang"}},
+		Stops:         [][]string{{"stop_id", "stop_name", "stop_lat", "stop_lon", "zone_id"}},
+		Routes:        [][]string{{"route_id", "agency_id", "route_short_name", "route_long_name", "route_type"}},
+		Trips:         [][]string{{"route_id", "service_id", "trip_id", "trip_headsign", "block_id"}},
+		StopTimes:     [][]string{{"trip_id", "arrival_time", "departure_time", "stop_id", "stop_sequence"}},
+		Calendar:      [][]string{{"service_id", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday", "start_date", "end_date"}},
+		CalendarDates: [][]string{{"service_id", "date", "exception_type"}},
+	}
+
+	routes := data.Routes()
+	tripIDs := make([]string, 0, len(routes))
+	for id := range routes {
+		tripIDs = append(tripIDs, id)
+	}
+	sort.Strings(tripIDs)
+
+	agencies := make(map[string]bool)
+	stops := make(map[string]bool)
+	lines := make(map[string]bool)
+	services := make(map[string]gtfsService)
+
+	for _, tripID := range tripIDs {
+		route := routes[tripID]
+		first := route.Stops[0].Point
+
+		if !agencies[first.City] {
+			agencies[first.City] = true
+			feed.Agencies = append(feed.Agencies, []string{
+				agencyID(first.City), first.City + "環保局", "https://data.gov.tw", "Asia/Taipei", "zh-TW",
+			})
+		}
+
+		lineID := gtfsID("r", first.City, route.Name)
+		if !lines[lineID] {
+			lines[lineID] = true
+			feed.Routes = append(feed.Routes, []string{
+				lineID, agencyID(first.City), route.Name, strings.TrimSpace(first.District + " " + route.Name), gtfsRouteType,
+			})
+		}
+
+		days := calendar.RouteServiceDays(route)
+		serviceID := agencyID(first.City) + "_" + gtfsServiceID(days)
+		service := gtfsService{days: days, point: &CollectionPoint{City: first.City}}
+		if calendar.hasRouteSpecialDays(first.City, route.VehicleNumber, route.Trip) {
+			serviceID = tripID + "_" + gtfsServiceID(days)
+			service.point = first
+		}
+		services[serviceID] = service
+
+		feed.Trips = append(feed.Trips, []string{lineID, serviceID, tripID, route.Trip, route.VehicleNumber})
+
+		// offset moves stops reached after midnight past 24:00, as GTFS expects
+		offset, previous := 0, -1
+		for _, stop := range route.Stops {
+			stopID := StopID(stop.Point)
+			if !stops[stopID] {
+				stops[stopID] = true
+				feed.Stops = append(feed.Stops, []string{
+					stopID, stop.Name, formatCoordinate(stop.Lat), formatCoordinate(stop.Lng), stop.Point.City,
+				})
+			}
+
+			arrival, err := clockMinutes(stop.Time)
+			if err != nil {
+				continue
+			}
+			departure, err := departureMinutes(arrival, stop.DepartureTime)
+			if err != nil || departure < arrival {
+				departure = arrival
+			}
+			if arrival+offset < previous {
+				offset += 24 * 60
+			}
+			arrival += offset
+			departure += offset
+			previous = arrival
+			feed.StopTimes = append(feed.StopTimes, []string{
+				tripID, gtfsTime(arrival), gtfsTime(departure), stopID, strconv.Itoa(stop.Sequence),
+			})
+		}
+	}
+
+	start = utils.ToTaiwan(start)
+	startDate := start.Format("20060102")
+	endDate := start.AddDate(gtfsServiceYears, 0, -1).Format("20060102")
+
+	serviceIDs := make([]string, 0, len(services))
+	for id := range services {
+		serviceIDs = append(serviceIDs, id)
+	}
+	sort.Strings(serviceIDs)
+
+	for _, id := range serviceIDs {
+		service := services[id]
+		row := []string{id}
+		for _, d := range gtfsWeekdays {
+			if service.days.Has(d) {
+				row = append(row, "1")
+			} else {
+				row = append(row, "0")
+			}
+		}
+		feed.Calendar = append(feed.Calendar, append(row, startDate, endDate))
+
+		for _, date := range calendar.SpecialDates(service.point.City) {
+			day, err := time.ParseInLocation("2006-01-02", date, start.Location())
+			if err != nil || day.Format("20060102") < startDate || day.Format("20060102") > endDate {
+				continue
+			}
+
+			special, ok := calendar.SpecialDayFor(service.point, day)
+			if !ok {
+				continue
+			}
+			switch {
+			case special.Type == SpecialDayExtra && !service.days.Has(day.Weekday()):
+				feed.CalendarDates = append(feed.CalendarDates, []string{id, day.Format("20060102"), gtfsServiceAdded})
+			case special.Type == SpecialDaySuspended && service.days.Has(day.Weekday()):
+				feed.CalendarDates = append(feed.CalendarDates, []string{id, day.Format("20060102"), gtfsServiceRemoved})
+			}
+		}
+	}
+
+	return feed
+}
+
+// WriteZip writes the feed as a zip archive of GTFS text files.
+func (f *GTFSFeed) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		rows [][]string
+	}{
+		{"agency.txt", f.Agencies},
+		{"stops.txt", f.Stops},
+		{"routes.txt", f.Routes},
+		{"trips.txt", f.Trips},
+		{"stop_times.txt", f.StopTimes},
+		{"calendar.txt", f.Calendar},
+		{"calendar_dates.txt", f.CalendarDates},
+	}
+
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", file.name, err)
+		}
+
+		cw := csv.NewWriter(fw)
+		if err := cw.WriteAll(file.rows); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	return zw.Close()
+}
+
+// StopID identifies a physical collection spot: the same location name at the
+// same coordinates in the same city.
+func StopID(point *CollectionPoint) string {
+	return gtfsID("s", point.City, point.Location, point.Latitude, point.Longitude)
+}
+
+func gtfsID(prefix string, parts ...string) string {
+	h := fnv.New64a()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%s%x", prefix, h.Sum64())
+}
+
+func agencyID(city string) string {
+	return gtfsID("a", city)
+}
+
+// gtfsServiceID names a weekday set by its Monday-first pattern, e.g. "wd_1101110".
+func gtfsServiceID(days Weekdays) string {
+	id := []byte("wd_")
+	for _, d := range gtfsWeekdays {
+		if days.Has(d) {
+			id = append(id, '1')
+		} else {
+			id = append(id, '0')
+		}
+	}
+	return string(id)
+}
+
+// gtfsTime renders minutes after midnight as "19:00:00". Times on the next day
+// go past 24:00, e.g. "24:05:00".
+func gtfsTime(minutes int) string {
+	return fmt.Sprintf("%02d:%02d:00", minutes/60, minutes%60)
+}
+
+func formatCoordinate(v float64) string {
+	return strconv.FormatFloat(v, 'f', 6, 64)
+}
@@ -0,0 +1,171 @@
+package garbage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"linebot-garbage-helper/internal/utils"
+)
+
+const (
+	// SpecialDaySuspended means no collection on a day that is normally served.
+	SpecialDaySuspended = "suspended"
+	// SpecialDayExtra means collection on a day that is normally not served.
+	SpecialDayExtra = "extra"
+)
+
+// SpecialDay is a holiday override of the weekday rules for one date.
+type SpecialDay struct {
+	Date string `json:"date"`
+	Type string `json:"type"`
+	Note string `json:"note"`
+	// Routes limits the override to these trips of the city, given as TripKeys
+	// or RouteKeys; empty means the whole city
+	Routes []string `json:"routes,omitempty"`
+}
+
+// HolidayCalendarFile is the layout of one city's holiday file for one year.
+type HolidayCalendarFile struct {
+	City string       `json:"city"`
+	Year int          `json:"year"`
+	Days []SpecialDay `json:"days"`
+}
+
+// LoadHolidayDir loads every *.json holiday file in dir, e.g. taipei-2026.json.
+func (c *CollectionCalendar) LoadHolidayDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := c.LoadHolidayFile(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadHolidayFile loads one city's holiday overrides.
+func (c *CollectionCalendar) LoadHolidayFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var file HolidayCalendarFile
+	if err := json.Unmarshal(b, &file); err != nil {
+		return fmt.Errorf("invalid holiday calendar %s: %w", path, err)
+	}
+	if file.City == "" {
+		return fmt.Errorf("invalid holiday calendar %s: missing city", path)
+	}
+
+	for _, day := range file.Days {
+		if _, err := time.Parse("2006-01-02", day.Date); err != nil {
+			return fmt.Errorf("invalid date %q in %s", day.Date, path)
+		}
+		if day.Type != SpecialDaySuspended && day.Type != SpecialDayExtra {
+			return fmt.Errorf("invalid type %q for %s in %s", day.Type, day.Date, path)
+		}
+		c.AddSpecialDay(file.City, day)
+	}
+
+	log.Printf("Loaded %d special days for %s %d from %s", len(file.Days), file.City, file.Year, path)
+	return nil
+}
+
+// AddSpecialDay adds an override for one date. A date can hold both a city-wide
+// entry and route-limited entries; adding the same scope twice replaces it.
+func (c *CollectionCalendar) AddSpecialDay(city string, day SpecialDay) {
+	if c.specialDays[city] == nil {
+		c.specialDays[city] = make(map[string][]SpecialDay)
+	}
+
+	days := c.specialDays[city][day.Date]
+	for i, existing := range days {
+		if slices.Equal(existing.Routes, day.Routes) {
+			days[i] = day
+			return
+		}
+	}
+	c.specialDays[city][day.Date] = append(days, day)
+}
+
+// SpecialDates returns the dates with overrides for the city, sorted.
+func (c *CollectionCalendar) SpecialDates(city string) []string {
+	dates := make([]string, 0, len(c.specialDays[city]))
+	for date := range c.specialDays[city] {
+		dates = append(dates, date)
+	}
+	slices.Sort(dates)
+	return dates
+}
+
+// hasRouteSpecialDays reports whether any override of the city is limited to
+// some routes including the vehicle's trip.
+func (c *CollectionCalendar) hasRouteSpecialDays(city, vehicleNumber, trip string) bool {
+	for _, days := range c.specialDays[city] {
+		for _, day := range days {
+			if day.coversTrip(city, vehicleNumber, trip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (day SpecialDay) coversTrip(city, vehicleNumber, trip string) bool {
+	return slices.Contains(day.Routes, TripKey(vehicleNumber, trip)) ||
+		slices.Contains(day.Routes, RouteKey(city, vehicleNumber, trip))
+}
+
+// SpecialDayFor returns the holiday override that applies to the point on the
+// date of t in Taiwan time. An entry limited to the point's route wins over a
+// city-wide entry.
+func (c *CollectionCalendar) SpecialDayFor(point *CollectionPoint, t time.Time) (SpecialDay, bool) {
+	var cityWide SpecialDay
+	found := false
+	for _, day := range c.specialDays[point.City][utils.ToTaiwan(t).Format("2006-01-02")] {
+		if len(day.Routes) == 0 {
+			cityWide, found = day, true
+			continue
+		}
+		if day.coversTrip(point.City, point.VehicleNumber, point.VehicleTrip) {
+			return day, true
+		}
+	}
+	return cityWide, found
+}
+
+// SuspensionBefore returns the first suspended day that pushed the point's next
+// arrival past from, so results can explain why the truck is not coming sooner.
+func (c *CollectionCalendar) SuspensionBefore(point *CollectionPoint, from, arrival time.Time) (SpecialDay, bool) {
+	minutes, err := clockMinutes(point.ArrivalTime)
+	if err != nil {
+		return SpecialDay{}, false
+	}
+
+	from = utils.ToTaiwan(from)
+	for d := 0; d <= maxCalendarLookahead; d++ {
+		day := from.AddDate(0, 0, d)
+		skipped := time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, from.Location())
+		if !skipped.Before(arrival) {
+			break
+		}
+		if skipped.Before(from) {
+			continue
+		}
+
+		special, ok := c.SpecialDayFor(point, skipped)
+		if ok && special.Type == SpecialDaySuspended {
+			return special, true
+		}
+	}
+	return SpecialDay{}, false
+}
@@ -0,0 +1,70 @@
+package garbage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Provider loads one city's open-data collection schedule and normalizes it
+// into the common CollectionPoint model.
+type Provider interface {
+	// Name is a short identifier used in logs, e.g. "taipei".
+	Name() string
+	// City is the city name stored in CollectionPoint.City, e.g. "台北市".
+	City() string
+	// Bounds is the rough area served by the provider and is used to route queries.
+	Bounds() BoundingBox
+	// Fetch downloads (or reads) the source and returns normalized collection points.
+	Fetch(ctx context.Context, client *http.Client) ([]CollectionPoint, error)
+}
+
+// pointID namespaces a row number by provider, so rows of different cities
+// never share an ID, e.g. "taoyuan-12".
+func pointID(provider Provider, row int) string {
+	return fmt.Sprintf("%s-%d", provider.Name(), row)
+}
+
+// BoundingBox is a latitude/longitude rectangle.
+type BoundingBox struct {
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+func (b BoundingBox) Contains(lat, lng float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lng >= b.MinLng && lng <= b.MaxLng
+}
+
+// openSource opens a provider source, which is either an http(s) URL or a local
+// file path. Local files make it possible to run a provider against a fixture.
+func openSource(ctx context.Context, client *http.Client, source string) (io.ReadCloser, error) {
+	if source == "" {
+		return nil, fmt.Errorf("empty data source")
+	}
+
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.Open(source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
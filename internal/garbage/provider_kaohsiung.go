@@ -0,0 +1,73 @@
+package garbage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// KaohsiungProvider reads the Kaohsiung City Environmental Protection Bureau
+// route dataset, which wraps its records in a "data" array.
+type KaohsiungProvider struct {
+	source string
+}
+
+type kaohsiungDataset struct {
+	Data []kaohsiungRecord `json:"data"`
+}
+
+type kaohsiungRecord struct {
+	District      string `json:"區別"`
+	Village       string `json:"里別"`
+	Route         string `json:"路線"`
+	VehicleNumber string `json:"車號"`
+	VehicleTrip   string `json:"班次"`
+	StopName      string `json:"停靠點名稱"`
+	StopTime      string `json:"停靠時間"`
+	Longitude     string `json:"經度"`
+	Latitude      string `json:"緯度"`
+}
+
+func NewKaohsiungProvider(source string) *KaohsiungProvider {
+	return &KaohsiungProvider{source: source}
+}
+
+func (p *KaohsiungProvider) Name() string { return "kaohsiung" }
+
+func (p *KaohsiungProvider) City() string { return "高雄市" }
+
+func (p *KaohsiungProvider) Bounds() BoundingBox {
+	return BoundingBox{MinLat: 22.46, MinLng: 120.17, MaxLat: 23.47, MaxLng: 121.05}
+}
+
+func (p *KaohsiungProvider) Fetch(ctx context.Context, client *http.Client) ([]CollectionPoint, error) {
+	body, err := openSource(ctx, client, p.source)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var dataset kaohsiungDataset
+	if err := json.NewDecoder(body).Decode(&dataset); err != nil {
+		return nil, err
+	}
+
+	points := make([]CollectionPoint, 0, len(dataset.Data))
+	for i, r := range dataset.Data {
+		points = append(points, CollectionPoint{
+			ID:            pointID(p, i+1),
+			City:          p.City(),
+			District:      r.District,
+			Neighborhood:  r.Village,
+			VehicleNumber: r.VehicleNumber,
+			Route:         r.Route,
+			VehicleTrip:   r.VehicleTrip,
+			ArrivalTime:   r.StopTime,
+			Location:      r.StopName,
+			Longitude:     r.Longitude,
+			Latitude:      r.Latitude,
+		})
+	}
+
+	return points, nil
+}
@@ -0,0 +1,97 @@
+package garbage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NTPCProvider reads the New Taipei City (ntpc.gov.tw) open-data "垃圾車路線"
+// dataset, a JSON array with one record per stop on each line.
+type NTPCProvider struct {
+	source string
+}
+
+type ntpcRecord struct {
+	District  string `json:"city"`
+	LineID    string `json:"lineid"`
+	LineName  string `json:"linename"`
+	Rank      string `json:"rank"`
+	Name      string `json:"name"`
+	Village   string `json:"village"`
+	Longitude string `json:"longitude"`
+	Latitude  string `json:"latitude"`
+	Time      string `json:"time"`
+	Memo      string `json:"memo"`
+
+	// Collection weekday flags, "Y" when general waste is collected that day
+	GarbageSunday    string `json:"garbagesunday"`
+	GarbageMonday    string `json:"garbagemonday"`
+	GarbageTuesday   string `json:"garbagetuesday"`
+	GarbageWednesday string `json:"garbagewednesday"`
+	GarbageThursday  string `json:"garbagethursday"`
+	GarbageFriday    string `json:"garbagefriday"`
+	GarbageSaturday  string `json:"garbagesaturday"`
+}
+
+func (r ntpcRecord) serviceDays() Weekdays {
+	flags := []string{
+		r.GarbageSunday, r.GarbageMonday, r.GarbageTuesday, r.GarbageWednesday,
+		r.GarbageThursday, r.GarbageFriday, r.GarbageSaturday,
+	}
+
+	var days Weekdays
+	for day, flag := range flags {
+		if strings.EqualFold(strings.TrimSpace(flag), "Y") {
+			days |= NewWeekdays(time.Weekday(day))
+		}
+	}
+	return days
+}
+
+func NewNTPCProvider(source string) *NTPCProvider {
+	return &NTPCProvider{source: source}
+}
+
+func (p *NTPCProvider) Name() string { return "new_taipei" }
+
+func (p *NTPCProvider) City() string { return "新北市" }
+
+func (p *NTPCProvider) Bounds() BoundingBox {
+	return BoundingBox{MinLat: 24.67, MinLng: 121.28, MaxLat: 25.30, MaxLng: 122.01}
+}
+
+func (p *NTPCProvider) Fetch(ctx context.Context, client *http.Client) ([]CollectionPoint, error) {
+	body, err := openSource(ctx, client, p.source)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var records []ntpcRecord
+	if err := json.NewDecoder(body).Decode(&records); err != nil {
+		return nil, err
+	}
+
+	points := make([]CollectionPoint, 0, len(records))
+	for i, r := range records {
+		points = append(points, CollectionPoint{
+			ID:            pointID(p, i+1),
+			City:          p.City(),
+			District:      r.District,
+			Neighborhood:  r.Village,
+			StationCode:   r.Rank,
+			VehicleNumber: r.LineID,
+			Route:         r.LineName,
+			ArrivalTime:   r.Time,
+			Location:      r.Name,
+			Longitude:     r.Longitude,
+			Latitude:      r.Latitude,
+			ServiceDays:   r.serviceDays(),
+		})
+	}
+
+	return points, nil
+}
@@ -0,0 +1,90 @@
+package garbage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// DefaultTaipeiSource is the Taipei City dataset mirrored by Yukaii/garbage.
+const DefaultTaipeiSource = "https://raw.githubusercontent.com/Yukaii/garbage/data/trash-collection-points.json"
+
+// TaipeiProvider reads the Taipei City Department of Environmental Protection
+// collection point dataset.
+type TaipeiProvider struct {
+	source string
+}
+
+type taipeiDataset struct {
+	Result struct {
+		Results []taipeiRecord `json:"results"`
+	} `json:"result"`
+}
+
+type taipeiRecord struct {
+	ID            int        `json:"_id"`
+	ImportDate    ImportDate `json:"_importdate"`
+	District      string     `json:"行政區"`
+	Neighborhood  string     `json:"里別"`
+	Squad         string     `json:"分隊"`
+	StationCode   string     `json:"局編"`
+	VehicleNumber string     `json:"車號"`
+	Route         string     `json:"路線"`
+	VehicleTrip   string     `json:"車次"`
+	ArrivalTime   string     `json:"抵達時間"`
+	DepartureTime string     `json:"離開時間"`
+	Location      string     `json:"地點"`
+	Longitude     string     `json:"經度"`
+	Latitude      string     `json:"緯度"`
+}
+
+func NewTaipeiProvider(source string) *TaipeiProvider {
+	if source == "" {
+		source = DefaultTaipeiSource
+	}
+	return &TaipeiProvider{source: source}
+}
+
+func (p *TaipeiProvider) Name() string { return "taipei" }
+
+func (p *TaipeiProvider) City() string { return "台北市" }
+
+func (p *TaipeiProvider) Bounds() BoundingBox {
+	return BoundingBox{MinLat: 24.96, MinLng: 121.45, MaxLat: 25.21, MaxLng: 121.67}
+}
+
+func (p *TaipeiProvider) Fetch(ctx context.Context, client *http.Client) ([]CollectionPoint, error) {
+	body, err := openSource(ctx, client, p.source)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var dataset taipeiDataset
+	if err := json.NewDecoder(body).Decode(&dataset); err != nil {
+		return nil, err
+	}
+
+	points := make([]CollectionPoint, 0, len(dataset.Result.Results))
+	for _, r := range dataset.Result.Results {
+		points = append(points, CollectionPoint{
+			ID:            pointID(p, r.ID),
+			ImportDate:    r.ImportDate,
+			City:          p.City(),
+			District:      r.District,
+			Neighborhood:  r.Neighborhood,
+			Squad:         r.Squad,
+			StationCode:   r.StationCode,
+			VehicleNumber: r.VehicleNumber,
+			Route:         r.Route,
+			VehicleTrip:   r.VehicleTrip,
+			ArrivalTime:   r.ArrivalTime,
+			DepartureTime: r.DepartureTime,
+			Location:      r.Location,
+			Longitude:     r.Longitude,
+			Latitude:      r.Latitude,
+		})
+	}
+
+	return points, nil
+}
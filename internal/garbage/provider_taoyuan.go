@@ -0,0 +1,71 @@
+package garbage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// TaoyuanProvider reads the Taoyuan City collection point dataset, a JSON array
+// keyed by the Chinese column names of the city's open-data CSV export.
+type TaoyuanProvider struct {
+	source string
+}
+
+type taoyuanRecord struct {
+	District      string `json:"行政區"`
+	Village       string `json:"村里"`
+	RouteName     string `json:"路線名稱"`
+	VehicleNumber string `json:"車號"`
+	VehicleTrip   string `json:"車次"`
+	Location      string `json:"清運點"`
+	ArrivalTime   string `json:"抵達時間"`
+	DepartureTime string `json:"離開時間"`
+	Longitude     string `json:"經度"`
+	Latitude      string `json:"緯度"`
+}
+
+func NewTaoyuanProvider(source string) *TaoyuanProvider {
+	return &TaoyuanProvider{source: source}
+}
+
+func (p *TaoyuanProvider) Name() string { return "taoyuan" }
+
+func (p *TaoyuanProvider) City() string { return "桃園市" }
+
+func (p *TaoyuanProvider) Bounds() BoundingBox {
+	return BoundingBox{MinLat: 24.58, MinLng: 120.97, MaxLat: 25.13, MaxLng: 121.48}
+}
+
+func (p *TaoyuanProvider) Fetch(ctx context.Context, client *http.Client) ([]CollectionPoint, error) {
+	body, err := openSource(ctx, client, p.source)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var records []taoyuanRecord
+	if err := json.NewDecoder(body).Decode(&records); err != nil {
+		return nil, err
+	}
+
+	points := make([]CollectionPoint, 0, len(records))
+	for i, r := range records {
+		points = append(points, CollectionPoint{
+			ID:            pointID(p, i+1),
+			City:          p.City(),
+			District:      r.District,
+			Neighborhood:  r.Village,
+			VehicleNumber: r.VehicleNumber,
+			Route:         r.RouteName,
+			VehicleTrip:   r.VehicleTrip,
+			ArrivalTime:   r.ArrivalTime,
+			DepartureTime: r.DepartureTime,
+			Location:      r.Location,
+			Longitude:     r.Longitude,
+			Latitude:      r.Latitude,
+		})
+	}
+
+	return points, nil
+}
@@ -0,0 +1,80 @@
+package garbage
+
+import (
+	"sort"
+	"time"
+
+	"linebot-garbage-helper/internal/geo"
+)
+
+// SortMode selects how search results are ordered.
+type SortMode string
+
+const (
+	// SortSoonest orders by arrival time.
+	SortSoonest SortMode = "soonest"
+	// SortClosest orders by walking distance.
+	SortClosest SortMode = "closest"
+	// SortBest puts catchable stops first and weighs walking against waiting.
+	SortBest SortMode = "best"
+)
+
+// walkWeight is how much more a minute of walking costs than a minute of waiting.
+const walkWeight = 2.0
+
+// ParseSortMode returns the mode named by s, defaulting to SortBest.
+func ParseSortMode(s string) SortMode {
+	switch SortMode(s) {
+	case SortSoonest, SortClosest:
+		return SortMode(s)
+	}
+	return SortBest
+}
+
+// Catchable reports whether the user, leaving now and walking to the stop, gets
+// there before the truck leaves on the ETA visit.
+func (s *NearestStop) Catchable(now time.Time) bool {
+	return !now.Add(geo.WalkingTime(s.Distance)).After(s.etaDeparture())
+}
+
+// Score is the cost of catching the stop in minutes: walking time weighted by
+// walkWeight plus the time left waiting at the stop. Lower is better.
+func (s *NearestStop) Score(now time.Time) float64 {
+	walk := geo.WalkingTime(s.Distance)
+	wait := s.ETA.Sub(now.Add(walk))
+	if wait < 0 {
+		wait = 0
+	}
+	return walk.Minutes()*walkWeight + wait.Minutes()
+}
+
+// etaDeparture is when the truck leaves on the ETA visit. Departure refers to
+// the visit just left when the status is StopStatusJustLeft.
+func (s *NearestStop) etaDeparture() time.Time {
+	if s.Status == StopStatusJustLeft && s.CollectionPoint != nil {
+		return s.ETA.Add(dwellTime(s.CollectionPoint))
+	}
+	return s.Departure
+}
+
+// RankStops orders stops in place by mode.
+func RankStops(stops []*NearestStop, mode SortMode, now time.Time) {
+	switch mode {
+	case SortSoonest:
+		sort.SliceStable(stops, func(i, j int) bool {
+			return stops[i].ETA.Before(stops[j].ETA)
+		})
+	case SortClosest:
+		sort.SliceStable(stops, func(i, j int) bool {
+			return stops[i].Distance < stops[j].Distance
+		})
+	default:
+		sort.SliceStable(stops, func(i, j int) bool {
+			ci, cj := stops[i].Catchable(now), stops[j].Catchable(now)
+			if ci != cj {
+				return ci
+			}
+			return stops[i].Score(now) < stops[j].Score(now)
+		})
+	}
+}
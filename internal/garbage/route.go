@@ -0,0 +1,204 @@
+package garbage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RouteKey identifies one trip (車次) of a vehicle in a city. Each key maps to
+// exactly one ordered Route in GarbageData.Routes. The city is part of the key
+// because vehicle numbers are only unique within one city's dataset.
+func RouteKey(city, vehicleNumber, trip string) string {
+	return city + ":" + TripKey(vehicleNumber, trip)
+}
+
+// TripKey identifies one trip of a vehicle within a city, e.g. "KES-1021_第1車".
+// It is the form used by per-city files and by reminders saved before route
+// keys carried the city.
+func TripKey(vehicleNumber, trip string) string {
+	if trip == "" {
+		return vehicleNumber
+	}
+	return vehicleNumber + "_" + trip
+}
+
+// BuildRoutes groups collection points by vehicle and trip and orders each
+// group by arrival time. Points with unparseable coordinates or times are
+// skipped; the others get the DayOffset of their position on the trip.
+func BuildRoutes(points []CollectionPoint) map[string]*Route {
+	type routeStop struct {
+		stop    Stop
+		minutes int
+	}
+
+	grouped := make(map[string][]routeStop)
+	names := make(map[string]string)
+
+	for i := range points {
+		point := &points[i]
+		if point.VehicleNumber == "" {
+			continue
+		}
+
+		lat, lng, err := parseCoordinates(point.Latitude, point.Longitude)
+		if err != nil {
+			continue
+		}
+
+		minutes, err := clockMinutes(point.ArrivalTime)
+		if err != nil {
+			continue
+		}
+
+		key := RouteKey(point.City, point.VehicleNumber, point.VehicleTrip)
+		if _, ok := names[key]; !ok {
+			names[key] = point.Route
+		}
+
+		grouped[key] = append(grouped[key], routeStop{
+			stop: Stop{
+				Name:          point.Location,
+				Lat:           lat,
+				Lng:           lng,
+				Time:          point.ArrivalTime,
+				DepartureTime: point.DepartureTime,
+				Point:         point,
+			},
+			minutes: minutes,
+		})
+	}
+
+	routes := make(map[string]*Route, len(grouped))
+	for key, stops := range grouped {
+		sort.SliceStable(stops, func(i, j int) bool {
+			return stops[i].minutes < stops[j].minutes
+		})
+
+		minutes := make([]int, len(stops))
+		for i, rs := range stops {
+			minutes[i] = rs.minutes
+		}
+		if start := tripStart(minutes); start > 0 {
+			stops = append(stops[start:len(stops):len(stops)], stops[:start]...)
+		}
+
+		first := stops[0].stop.Point
+		route := &Route{
+			ID:            key,
+			Name:          names[key],
+			City:          first.City,
+			VehicleNumber: first.VehicleNumber,
+			Trip:          first.VehicleTrip,
+			Stops:         make([]Stop, len(stops)),
+		}
+		for i, rs := range stops {
+			rs.stop.Sequence = i + 1
+			route.Stops[i] = rs.stop
+		}
+		for i, offset := range dayOffsets(route) {
+			route.Stops[i].Point.DayOffset = offset
+		}
+		routes[key] = route
+	}
+
+	return routes
+}
+
+// tripStart returns the index of the first stop of a trip whose sorted clock
+// times are minutes. A trip that runs past midnight starts after the longest gap
+// between its stops rather than at the earliest clock time.
+func tripStart(minutes []int) int {
+	if len(minutes) < 2 {
+		return 0
+	}
+
+	start := 0
+	longest := minutes[0] + 24*60 - minutes[len(minutes)-1]
+	for i := 1; i < len(minutes); i++ {
+		if gap := minutes[i] - minutes[i-1]; gap > longest {
+			start, longest = i, gap
+		}
+	}
+	return start
+}
+
+// dayOffsets returns, for each stop of the route, how many days after the trip
+// started the truck reaches it. Stops whose time cannot be parsed keep the
+// offset of the stop before them.
+func dayOffsets(route *Route) []int {
+	offsets := make([]int, len(route.Stops))
+	day, previous := 0, -1
+	for i, stop := range route.Stops {
+		if minutes, err := clockMinutes(stop.Time); err == nil {
+			if minutes < previous {
+				day++
+			}
+			previous = minutes
+		}
+		offsets[i] = day
+	}
+	return offsets
+}
+
+// StopIndex returns the position of the named stop in the route, or -1.
+func (r *Route) StopIndex(stopName string) int {
+	for i, stop := range r.Stops {
+		if stop.Name == stopName {
+			return i
+		}
+	}
+	return -1
+}
+
+// Neighbors returns the stops before and after the named stop. Either may be nil
+// at the ends of the route or when the stop is not on the route.
+func (r *Route) Neighbors(stopName string) (previous, next *Stop) {
+	i := r.StopIndex(stopName)
+	if i < 0 {
+		return nil, nil
+	}
+	if i > 0 {
+		previous = &r.Stops[i-1]
+	}
+	if i < len(r.Stops)-1 {
+		next = &r.Stops[i+1]
+	}
+	return previous, next
+}
+
+// FormatClock renders dataset times such as "1900" as "19:00". Times that
+// cannot be parsed are returned unchanged.
+func FormatClock(timeStr string) string {
+	minutes, err := clockMinutes(timeStr)
+	if err != nil {
+		return timeStr
+	}
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// clockMinutes parses "1504" or "15:04" into minutes after midnight.
+func clockMinutes(timeStr string) (int, error) {
+	s := strings.ReplaceAll(strings.TrimSpace(timeStr), ":", "")
+	if len(s) == 3 {
+		s = "0" + s
+	}
+	if len(s) != 4 {
+		return 0, fmt.Errorf("invalid time: %s", timeStr)
+	}
+
+	hour, err := strconv.Atoi(s[:2])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time: %s", timeStr)
+	}
+	minute, err := strconv.Atoi(s[2:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time: %s", timeStr)
+	}
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid time: %s", timeStr)
+	}
+
+	return hour*60 + minute, nil
+}
@@ -0,0 +1,234 @@
+package garbage
+
+import (
+	"math"
+	"sort"
+
+	"linebot-garbage-helper/internal/geo"
+)
+
+// defaultCellSize is the grid cell edge in degrees, roughly 500 m in Taiwan.
+const defaultCellSize = 0.005
+
+// metersPerDegreeLat is the approximate length of one degree of latitude.
+const metersPerDegreeLat = 111320.0
+
+// SpatialIndex buckets collection points into a fixed latitude/longitude grid so
+// radius and k-nearest queries only visit cells close to the query point.
+type SpatialIndex struct {
+	cellSize float64
+	cells    map[gridCell][]int
+	points   []indexedPoint
+
+	minRow, maxRow int
+	minCol, maxCol int
+}
+
+// IndexMatch is a collection point found by a SpatialIndex query.
+type IndexMatch struct {
+	// Index is the position of the point in GarbageData.Result.Results.
+	Index    int
+	Lat      float64
+	Lng      float64
+	Distance float64
+}
+
+type gridCell struct {
+	row int
+	col int
+}
+
+type indexedPoint struct {
+	index int
+	lat   float64
+	lng   float64
+}
+
+// NewSpatialIndex indexes every point with parseable coordinates.
+func NewSpatialIndex(points []CollectionPoint) *SpatialIndex {
+	si := &SpatialIndex{
+		cellSize: defaultCellSize,
+		cells:    make(map[gridCell][]int),
+	}
+
+	for i := range points {
+		lat, lng, err := parseCoordinates(points[i].Latitude, points[i].Longitude)
+		if err != nil {
+			continue
+		}
+
+		cell := si.cellFor(lat, lng)
+		if len(si.points) == 0 {
+			si.minRow, si.maxRow, si.minCol, si.maxCol = cell.row, cell.row, cell.col, cell.col
+		} else {
+			si.minRow = min(si.minRow, cell.row)
+			si.maxRow = max(si.maxRow, cell.row)
+			si.minCol = min(si.minCol, cell.col)
+			si.maxCol = max(si.maxCol, cell.col)
+		}
+
+		si.cells[cell] = append(si.cells[cell], len(si.points))
+		si.points = append(si.points, indexedPoint{index: i, lat: lat, lng: lng})
+	}
+
+	return si
+}
+
+// Len returns the number of indexed points.
+func (si *SpatialIndex) Len() int {
+	return len(si.points)
+}
+
+// Within returns the accepted points within radius meters, sorted by distance.
+// A nil accept function accepts every point.
+func (si *SpatialIndex) Within(lat, lng, radius float64, accept func(int) bool) []IndexMatch {
+	dLat := radius / metersPerDegreeLat
+	dLng := radius / (metersPerDegreeLat * math.Cos(lat*math.Pi/180))
+
+	from := si.cellFor(lat-dLat, lng-dLng)
+	to := si.cellFor(lat+dLat, lng+dLng)
+
+	var matches []IndexMatch
+	for row := from.row; row <= to.row; row++ {
+		for col := from.col; col <= to.col; col++ {
+			matches = si.collectCell(matches, gridCell{row, col}, lat, lng, accept, radius)
+		}
+	}
+
+	sortMatches(matches)
+	return matches
+}
+
+// Nearest returns up to k accepted points closest to the coordinates, sorted by
+// distance. It searches rings of cells outwards and stops as soon as no
+// unvisited cell can hold a closer point.
+func (si *SpatialIndex) Nearest(lat, lng float64, k int, accept func(int) bool) []IndexMatch {
+	if k <= 0 || len(si.points) == 0 {
+		return nil
+	}
+
+	center := si.cellFor(lat, lng)
+	maxRing := max(
+		abs(center.row-si.minRow), abs(center.row-si.maxRow),
+		abs(center.col-si.minCol), abs(center.col-si.maxCol),
+	)
+
+	var matches []IndexMatch
+	for ring := 0; ring <= maxRing; ring++ {
+		for _, cell := range ringCells(center, ring) {
+			matches = si.collectCell(matches, cell, lat, lng, accept, 0)
+		}
+
+		if len(matches) >= k {
+			sortMatches(matches)
+			if matches[k-1].Distance <= si.visitedRadius(lat, lng, center, ring) {
+				break
+			}
+		}
+	}
+
+	sortMatches(matches)
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+// All returns every accepted point sorted by distance.
+func (si *SpatialIndex) All(lat, lng float64, accept func(int) bool) []IndexMatch {
+	matches := make([]IndexMatch, 0, len(si.points))
+	for _, p := range si.points {
+		if accept != nil && !accept(p.index) {
+			continue
+		}
+		matches = append(matches, IndexMatch{
+			Index:    p.index,
+			Lat:      p.lat,
+			Lng:      p.lng,
+			Distance: geo.CalculateDistance(lat, lng, p.lat, p.lng),
+		})
+	}
+
+	sortMatches(matches)
+	return matches
+}
+
+func (si *SpatialIndex) cellFor(lat, lng float64) gridCell {
+	return gridCell{
+		row: int(math.Floor(lat / si.cellSize)),
+		col: int(math.Floor(lng / si.cellSize)),
+	}
+}
+
+// collectCell appends the accepted points of one cell. A positive radius drops
+// points farther than radius meters.
+func (si *SpatialIndex) collectCell(matches []IndexMatch, cell gridCell, lat, lng float64, accept func(int) bool, radius float64) []IndexMatch {
+	for _, pi := range si.cells[cell] {
+		p := si.points[pi]
+		if accept != nil && !accept(p.index) {
+			continue
+		}
+
+		distance := geo.CalculateDistance(lat, lng, p.lat, p.lng)
+		if radius > 0 && distance > radius {
+			continue
+		}
+
+		matches = append(matches, IndexMatch{Index: p.index, Lat: p.lat, Lng: p.lng, Distance: distance})
+	}
+	return matches
+}
+
+// visitedRadius is a conservative lower bound, in meters, on the distance from
+// the query point to any cell outside the visited square of rings.
+func (si *SpatialIndex) visitedRadius(lat, lng float64, center gridCell, ring int) float64 {
+	south := float64(center.row-ring) * si.cellSize
+	north := float64(center.row+ring+1) * si.cellSize
+	west := float64(center.col-ring) * si.cellSize
+	east := float64(center.col+ring+1) * si.cellSize
+
+	// Longitude degrees are shortest at the edge farthest from the equator
+	widestLat := math.Max(math.Abs(south), math.Abs(north))
+	metersPerDegreeLng := metersPerDegreeLat * math.Cos(widestLat*math.Pi/180)
+
+	latMargin := math.Min(lat-south, north-lat) * metersPerDegreeLat
+	lngMargin := math.Min(lng-west, east-lng) * metersPerDegreeLng
+
+	// Leave slack for the difference between haversine and the flat grid
+	return math.Min(latMargin, lngMargin) * 0.99
+}
+
+// ringCells returns the cells at Chebyshev distance ring from center.
+func ringCells(center gridCell, ring int) []gridCell {
+	if ring == 0 {
+		return []gridCell{center}
+	}
+
+	cells := make([]gridCell, 0, 8*ring)
+	for d := -ring; d <= ring; d++ {
+		cells = append(cells,
+			gridCell{center.row - ring, center.col + d},
+			gridCell{center.row + ring, center.col + d},
+		)
+	}
+	for d := -ring + 1; d <= ring-1; d++ {
+		cells = append(cells,
+			gridCell{center.row + d, center.col - ring},
+			gridCell{center.row + d, center.col + ring},
+		)
+	}
+	return cells
+}
+
+func sortMatches(matches []IndexMatch) {
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Distance < matches[j].Distance
+	})
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,76 @@
+package garbage
+
+import (
+	"time"
+)
+
+// StopStatus describes where the truck is relative to a stop right now.
+type StopStatus string
+
+const (
+	StopStatusUpcoming StopStatus = "upcoming"
+	StopStatusAtStop   StopStatus = "at_stop"
+	StopStatusJustLeft StopStatus = "just_left"
+)
+
+const (
+	// defaultDwell is assumed when a point has no usable departure time.
+	defaultDwell = 5 * time.Minute
+	// justLeftWindow is how long after departure a stop is reported as just left.
+	justLeftWindow = 15 * time.Minute
+	// maxOvernightDwell is the longest stay accepted across midnight, e.g. 2355→0005.
+	maxOvernightDwell = 2 * time.Hour
+)
+
+// dwellTime returns how long the truck stays at the point.
+func dwellTime(point *CollectionPoint) time.Duration {
+	arrival, err := clockMinutes(point.ArrivalTime)
+	if err != nil {
+		return defaultDwell
+	}
+
+	departure, err := departureMinutes(arrival, point.DepartureTime)
+	if err != nil || departure < arrival {
+		return defaultDwell
+	}
+
+	return time.Duration(departure-arrival) * time.Minute
+}
+
+// departureMinutes parses the departure time relative to the midnight before
+// arrival. A departure shortly after midnight belongs to the next day and is
+// returned past 24:00; other departures earlier than arrival are left as is.
+func departureMinutes(arrival int, timeStr string) (int, error) {
+	departure, err := clockMinutes(timeStr)
+	if err != nil {
+		return 0, err
+	}
+	if departure < arrival && time.Duration(departure+24*60-arrival)*time.Minute <= maxOvernightDwell {
+		departure += 24 * 60
+	}
+	return departure, nil
+}
+
+// stopWindow finds the visit of the point that matters at now: the one still in
+// progress or that ended within justLeftWindow, otherwise the next one. It
+// returns that visit's arrival and departure and the resulting status.
+func (c *CollectionCalendar) stopWindow(point *CollectionPoint, now time.Time) (arrival, departure time.Time, status StopStatus, err error) {
+	dwell := dwellTime(point)
+
+	arrival, err = c.NextArrival(point, now.Add(-dwell-justLeftWindow))
+	if err != nil {
+		return time.Time{}, time.Time{}, "", err
+	}
+	departure = arrival.Add(dwell)
+
+	switch {
+	case now.Before(arrival):
+		status = StopStatusUpcoming
+	case !now.After(departure):
+		status = StopStatusAtStop
+	default:
+		status = StopStatusJustLeft
+	}
+
+	return arrival, departure, status, nil
+}
@@ -0,0 +1,117 @@
+package garbage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// nearestOverfetch is how many index candidates are fetched per requested
+// location, since several trips usually share one location.
+const nearestOverfetch = 4
+
+// StopGroup is one physical collection spot with every trip that serves it.
+type StopGroup struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	City     string  `json:"city"`
+	District string  `json:"district"`
+	Lat      float64 `json:"lat"`
+	Lng      float64 `json:"lng"`
+
+	// Points are the trips serving the spot, ordered by arrival time
+	Points []*CollectionPoint `json:"-"`
+}
+
+// BuildStopGroups groups collection points by StopID. Points with unparseable
+// coordinates or times are skipped.
+func BuildStopGroups(points []CollectionPoint) map[string]*StopGroup {
+	groups := make(map[string]*StopGroup)
+
+	for i := range points {
+		point := &points[i]
+
+		lat, lng, err := parseCoordinates(point.Latitude, point.Longitude)
+		if err != nil {
+			continue
+		}
+		if _, err := clockMinutes(point.ArrivalTime); err != nil {
+			continue
+		}
+
+		id := StopID(point)
+		group, ok := groups[id]
+		if !ok {
+			group = &StopGroup{
+				ID:       id,
+				Name:     point.Location,
+				City:     point.City,
+				District: point.District,
+				Lat:      lat,
+				Lng:      lng,
+			}
+			groups[id] = group
+		}
+		group.Points = append(group.Points, point)
+	}
+
+	for _, group := range groups {
+		sort.SliceStable(group.Points, func(i, j int) bool {
+			a, _ := clockMinutes(group.Points[i].ArrivalTime)
+			b, _ := clockMinutes(group.Points[j].ArrivalTime)
+			return a < b
+		})
+	}
+
+	return groups
+}
+
+// StopGroups returns every collection spot keyed by StopID, building them on first use.
+func (d *GarbageData) StopGroups() map[string]*StopGroup {
+	d.stopGroupsOnce.Do(func() {
+		d.stopGroups = BuildStopGroups(d.Result.Results)
+	})
+	return d.stopGroups
+}
+
+// NextVisit returns the soonest visit to the collection spot among the trips
+// serving it, with the ETA computed as of now.
+func (ga *GarbageAdapter) NextVisit(data *GarbageData, group *StopGroup, now time.Time) (*NearestStop, error) {
+	var next *NearestStop
+	for _, point := range group.Points {
+		stop, err := ga.newNearestStop(data, point, IndexMatch{Lat: group.Lat, Lng: group.Lng}, now)
+		if err != nil {
+			continue
+		}
+		if next == nil || stop.ETA.Before(next.ETA) {
+			next = stop
+		}
+	}
+	if next == nil {
+		return nil, fmt.Errorf("no upcoming visit to %s", group.Name)
+	}
+	next.Trips = len(group.Points)
+	return next, nil
+}
+
+// collapseByStop keeps the first NearestStop of each location, preserving order,
+// and records how many trips serve it.
+func collapseByStop(data *GarbageData, stops []*NearestStop) []*NearestStop {
+	groups := data.StopGroups()
+	seen := make(map[string]bool)
+
+	collapsed := make([]*NearestStop, 0, len(stops))
+	for _, stop := range stops {
+		if seen[stop.StopID] {
+			continue
+		}
+		seen[stop.StopID] = true
+
+		if group, ok := groups[stop.StopID]; ok {
+			stop.Trips = len(group.Points)
+		}
+		collapsed = append(collapsed, stop)
+	}
+
+	return collapsed
+}
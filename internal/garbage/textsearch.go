@@ -0,0 +1,246 @@
+package garbage
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"linebot-garbage-helper/internal/geo"
+)
+
+const (
+	// minLocationScore is the share of query bigrams a location must contain
+	minLocationScore = 0.75
+	// maxLocationMatches caps how many locations one text search returns
+	maxLocationMatches = 10
+)
+
+// chineseDigits converts the section numbers used in road names (二段 → 2段).
+var chineseDigits = map[rune]rune{
+	'一': '1', '二': '2', '三': '3', '四': '4', '五': '5',
+	'六': '6', '七': '7', '八': '8', '九': '9',
+}
+
+// locationReplacer removes formatting that differs between sources and users
+// without changing the place, such as 27巷口 versus 27巷 or 30號 versus 30.
+var locationReplacer = strings.NewReplacer(
+	"臺", "台",
+	"巷口", "巷",
+	"弄口", "弄",
+	"號", "",
+	"号", "",
+)
+
+// LocationIndex is an n-gram index over collection point locations, for finding
+// stops and landmarks such as "全家 復興店" by name.
+type LocationIndex struct {
+	groups   map[string]*StopGroup
+	postings map[string][]string
+}
+
+// LocationMatch is a collection spot found by name.
+type LocationMatch struct {
+	Group *StopGroup
+	// Score is the share of the query's bigrams found in the location, from 0 to 1
+	Score float64
+}
+
+// NewLocationIndex indexes the location names of the stop groups.
+func NewLocationIndex(groups map[string]*StopGroup) *LocationIndex {
+	li := &LocationIndex{
+		groups:   groups,
+		postings: make(map[string][]string),
+	}
+
+	for id, group := range groups {
+		grams := locationGrams(group.Name)
+		// Single tokens too, for queries that split a name apart such as "27 巷"
+		for _, tokens := range locationTokens(group.Name) {
+			for _, token := range tokens {
+				grams[token] = true
+			}
+		}
+
+		for gram := range grams {
+			li.postings[gram] = append(li.postings[gram], id)
+		}
+	}
+
+	return li
+}
+
+// Search returns the locations containing most of the query's bigrams, best
+// first. Every number in the query must also appear in the location, so 27巷
+// never matches 29巷.
+func (li *LocationIndex) Search(query string) []LocationMatch {
+	grams := locationGrams(query)
+	// A single character such as 家 is too vague to search for
+	if len(grams) < 2 {
+		return nil
+	}
+	numbers := locationNumbers(query)
+
+	hits := make(map[string]int)
+	for gram := range grams {
+		for _, id := range li.postings[gram] {
+			hits[id]++
+		}
+	}
+
+	var matches []LocationMatch
+	for id, count := range hits {
+		score := float64(count) / float64(len(grams))
+		if score < minLocationScore {
+			continue
+		}
+
+		group := li.groups[id]
+		if !containsNumbers(locationNumbers(group.Name), numbers) {
+			continue
+		}
+		matches = append(matches, LocationMatch{Group: group, Score: score})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		// 分數相同時，名稱越短代表查詢涵蓋的比例越高
+		a, b := len([]rune(matches[i].Group.Name)), len([]rune(matches[j].Group.Name))
+		if a != b {
+			return a < b
+		}
+		return matches[i].Group.ID < matches[j].Group.ID
+	})
+
+	if len(matches) > maxLocationMatches {
+		matches = matches[:maxLocationMatches]
+	}
+	return matches
+}
+
+// BestMatches returns the leading matches sharing the top score, which are all
+// equally likely to be the place the user means.
+func BestMatches(matches []LocationMatch) []LocationMatch {
+	var best []LocationMatch
+	for _, match := range matches {
+		if match.Score == matches[0].Score {
+			best = append(best, match)
+		}
+	}
+	return best
+}
+
+// MatchesAtAddress keeps the matches whose location is the same street address
+// as addr, for queries that are a full address rather than a stop name. The
+// city and district are only compared when addr has them.
+func MatchesAtAddress(matches []LocationMatch, addr geo.Address) []LocationMatch {
+	var same []LocationMatch
+	for _, match := range matches {
+		location := geo.ParseAddress(match.Group.Name)
+		if location.Street() != addr.Street() {
+			continue
+		}
+		if (addr.City != "" && location.City != addr.City) || (addr.District != "" && location.District != addr.District) {
+			continue
+		}
+		same = append(same, match)
+	}
+	return same
+}
+
+// LocationIndex returns the location name index, building it on first use.
+func (d *GarbageData) LocationIndex() *LocationIndex {
+	d.locationIndexOnce.Do(func() {
+		d.locationIndex = NewLocationIndex(d.StopGroups())
+	})
+	return d.locationIndex
+}
+
+// locationTokens splits normalized text into segments at whitespace and
+// punctuation, and each segment into tokens: one per character, with a run of
+// digits kept as a single token.
+func locationTokens(text string) [][]string {
+	runes := []rune(locationReplacer.Replace(text))
+
+	var segments [][]string
+	var tokens []string
+	prevDigit := false
+	for i, r := range runes {
+		r = unicode.ToLower(halfWidth(r))
+		if digit, ok := chineseDigits[r]; ok && i+1 < len(runes) && runes[i+1] == '段' {
+			r = digit
+		}
+
+		isDigit := unicode.IsDigit(r)
+		switch {
+		case isDigit && prevDigit:
+			tokens[len(tokens)-1] += string(r)
+		case isDigit || unicode.IsLetter(r):
+			tokens = append(tokens, string(r))
+		case len(tokens) > 0:
+			segments = append(segments, tokens)
+			tokens = nil
+		}
+		prevDigit = isDigit
+	}
+	if len(tokens) > 0 {
+		segments = append(segments, tokens)
+	}
+
+	return segments
+}
+
+// locationGrams returns the token bigrams of each segment, or the token itself
+// for one-token segments.
+func locationGrams(text string) map[string]bool {
+	grams := make(map[string]bool)
+	for _, tokens := range locationTokens(text) {
+		if len(tokens) == 1 {
+			grams[tokens[0]] = true
+			continue
+		}
+		for i := 1; i < len(tokens); i++ {
+			grams[tokens[i-1]+"|"+tokens[i]] = true
+		}
+	}
+	return grams
+}
+
+func locationNumbers(text string) map[string]bool {
+	numbers := make(map[string]bool)
+	for _, tokens := range locationTokens(text) {
+		for _, token := range tokens {
+			if isNumberToken(token) {
+				numbers[token] = true
+			}
+		}
+	}
+	return numbers
+}
+
+func containsNumbers(have, want map[string]bool) bool {
+	for number := range want {
+		if !have[number] {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumberToken(token string) bool {
+	for _, r := range token {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return token != ""
+}
+
+// halfWidth converts full-width digits and letters typed on phones.
+func halfWidth(r rune) rune {
+	if r >= '０' && r <= 'ｚ' {
+		return r - '０' + '0'
+	}
+	return r
+}
@@ -0,0 +1,146 @@
+package garbage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IssueKind classifies a problem found in a dataset row.
+type IssueKind string
+
+const (
+	IssueInvalidCoordinates     IssueKind = "invalid_coordinates"
+	IssueOutsideTaiwan          IssueKind = "outside_taiwan"
+	IssueSwappedLatLng          IssueKind = "swapped_lat_lng"
+	IssueInvalidArrivalTime     IssueKind = "invalid_arrival_time"
+	IssueDepartureBeforeArrival IssueKind = "departure_before_arrival"
+	IssueDuplicateRow           IssueKind = "duplicate_row"
+)
+
+// maxIssueSamples is how many example rows are kept per issue kind.
+const maxIssueSamples = 5
+
+// taiwanBounds covers Taiwan proper and the outlying islands, Kinmen and Matsu included.
+var taiwanBounds = BoundingBox{MinLat: 21.8, MinLng: 118.1, MaxLat: 26.5, MaxLng: 122.1}
+
+// ValidationIssue is one problem found in one row.
+type ValidationIssue struct {
+	Kind     IssueKind `json:"kind"`
+	PointID  string    `json:"point_id"`
+	City     string    `json:"city"`
+	Location string    `json:"location"`
+	Detail   string    `json:"detail"`
+}
+
+// CityHealth summarizes the rows of one city.
+type CityHealth struct {
+	Total   int `json:"total"`
+	Flagged int `json:"flagged"`
+}
+
+// HealthReport summarizes the problems found in a dataset snapshot.
+type HealthReport struct {
+	GeneratedAt time.Time                       `json:"generated_at"`
+	TotalPoints int                             `json:"total_points"`
+	FlaggedRows int                             `json:"flagged_rows"`
+	Counts      map[IssueKind]int               `json:"counts"`
+	Samples     map[IssueKind][]ValidationIssue `json:"samples"`
+	Cities      map[string]CityHealth           `json:"cities"`
+}
+
+// ValidateDataset classifies the problems of every row. A row may have more than
+// one issue but is counted once in FlaggedRows.
+func ValidateDataset(points []CollectionPoint) *HealthReport {
+	report := &HealthReport{
+		GeneratedAt: time.Now(),
+		TotalPoints: len(points),
+		Counts:      make(map[IssueKind]int),
+		Samples:     make(map[IssueKind][]ValidationIssue),
+		Cities:      make(map[string]CityHealth),
+	}
+
+	seen := make(map[string]string)
+
+	for i := range points {
+		point := &points[i]
+		issues := validatePoint(point)
+
+		key := strings.Join([]string{point.City, point.VehicleNumber, point.VehicleTrip, point.Location, point.ArrivalTime}, "|")
+		if firstID, ok := seen[key]; ok {
+			issues = append(issues, ValidationIssue{
+				Kind:   IssueDuplicateRow,
+				Detail: fmt.Sprintf("same vehicle, trip, location and time as row %s", firstID),
+			})
+		} else {
+			seen[key] = point.ID
+		}
+
+		city := report.Cities[point.City]
+		city.Total++
+
+		if len(issues) > 0 {
+			report.FlaggedRows++
+			city.Flagged++
+		}
+		report.Cities[point.City] = city
+
+		for _, issue := range issues {
+			issue.PointID = point.ID
+			issue.City = point.City
+			issue.Location = point.Location
+
+			report.Counts[issue.Kind]++
+			if len(report.Samples[issue.Kind]) < maxIssueSamples {
+				report.Samples[issue.Kind] = append(report.Samples[issue.Kind], issue)
+			}
+		}
+	}
+
+	return report
+}
+
+func validatePoint(point *CollectionPoint) []ValidationIssue {
+	var issues []ValidationIssue
+
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(point.Latitude), 64)
+	lng, lngErr := strconv.ParseFloat(strings.TrimSpace(point.Longitude), 64)
+	switch {
+	case latErr != nil || lngErr != nil:
+		issues = append(issues, ValidationIssue{
+			Kind:   IssueInvalidCoordinates,
+			Detail: fmt.Sprintf("lat=%q lng=%q", point.Latitude, point.Longitude),
+		})
+	case taiwanBounds.Contains(lat, lng):
+		// valid
+	case taiwanBounds.Contains(lng, lat):
+		issues = append(issues, ValidationIssue{
+			Kind:   IssueSwappedLatLng,
+			Detail: fmt.Sprintf("lat=%s lng=%s", point.Latitude, point.Longitude),
+		})
+	default:
+		issues = append(issues, ValidationIssue{
+			Kind:   IssueOutsideTaiwan,
+			Detail: fmt.Sprintf("lat=%s lng=%s", point.Latitude, point.Longitude),
+		})
+	}
+
+	arrival, err := clockMinutes(point.ArrivalTime)
+	if err != nil {
+		issues = append(issues, ValidationIssue{
+			Kind:   IssueInvalidArrivalTime,
+			Detail: fmt.Sprintf("arrival=%q", point.ArrivalTime),
+		})
+	} else if point.DepartureTime != "" {
+		departure, err := departureMinutes(arrival, point.DepartureTime)
+		if err == nil && departure < arrival {
+			issues = append(issues, ValidationIssue{
+				Kind:   IssueDepartureBeforeArrival,
+				Detail: fmt.Sprintf("arrival=%s departure=%s", point.ArrivalTime, point.DepartureTime),
+			})
+		}
+	}
+
+	return issues
+}
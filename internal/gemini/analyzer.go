@@ -0,0 +1,69 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Analyzer understands the user's messages: what they ask for, where and when.
+type Analyzer interface {
+	AnalyzeIntent(ctx context.Context, userMessage string) (*IntentResult, error)
+	ExtractLocationFromText(ctx context.Context, text string) (string, error)
+	ParseTimeWindow(timeWindow TimeWindow) (time.Time, time.Time, error)
+}
+
+// FallbackAnalyzer asks the primary analyzer and, when it fails, e.g. because
+// the Gemini quota has run out, the fallback.
+type FallbackAnalyzer struct {
+	primary  Analyzer
+	fallback Analyzer
+}
+
+func NewFallbackAnalyzer(primary, fallback Analyzer) *FallbackAnalyzer {
+	return &FallbackAnalyzer{primary: primary, fallback: fallback}
+}
+
+func (fa *FallbackAnalyzer) AnalyzeIntent(ctx context.Context, userMessage string) (*IntentResult, error) {
+	result, err := fa.primary.AnalyzeIntent(ctx, userMessage)
+	if err == nil || !shouldFallback(ctx, err) {
+		return result, err
+	}
+
+	log.Printf("Primary analyzer failed for '%s', using fallback: %v", userMessage, err)
+	result, fallbackErr := fa.fallback.AnalyzeIntent(ctx, userMessage)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%w (fallback: %v)", err, fallbackErr)
+	}
+	return result, nil
+}
+
+func (fa *FallbackAnalyzer) ExtractLocationFromText(ctx context.Context, text string) (string, error) {
+	location, err := fa.primary.ExtractLocationFromText(ctx, text)
+	if err == nil || !shouldFallback(ctx, err) {
+		return location, err
+	}
+
+	log.Printf("Primary location extraction failed for '%s', using fallback: %v", text, err)
+	location, fallbackErr := fa.fallback.ExtractLocationFromText(ctx, text)
+	if fallbackErr != nil {
+		return "", fmt.Errorf("%w (fallback: %v)", err, fallbackErr)
+	}
+	return location, nil
+}
+
+func (fa *FallbackAnalyzer) ParseTimeWindow(timeWindow TimeWindow) (time.Time, time.Time, error) {
+	from, to, err := fa.primary.ParseTimeWindow(timeWindow)
+	if err == nil {
+		return from, to, nil
+	}
+	return fa.fallback.ParseTimeWindow(timeWindow)
+}
+
+// shouldFallback is false when the request itself was cancelled, since the
+// fallback would be cancelled too.
+func shouldFallback(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,67 @@
+package gemini
+
+import "strings"
+
+// IntentType classifies what a text message asks for.
+type IntentType string
+
+const (
+	// IntentFindTrucks asks where or when the garbage truck comes
+	IntentFindTrucks     IntentType = "find_trucks"
+	IntentSetReminder    IntentType = "set_reminder"
+	IntentCancelReminder IntentType = "cancel_reminder"
+	IntentListFavorites  IntentType = "list_favorites"
+	IntentAddFavorite    IntentType = "add_favorite"
+	IntentDeleteFavorite IntentType = "delete_favorite"
+	// IntentSortResults asks which stop is nearest, soonest or best
+	IntentSortResults IntentType = "sort_results"
+	IntentHelp        IntentType = "help"
+	IntentGreeting    IntentType = "greeting"
+)
+
+// intentTypes lists every intent the model may return.
+var intentTypes = []IntentType{
+	IntentFindTrucks, IntentSetReminder, IntentCancelReminder,
+	IntentListFavorites, IntentAddFavorite, IntentDeleteFavorite,
+	IntentSortResults, IntentHelp, IntentGreeting,
+}
+
+// ParseIntentType maps the model's answer to an intent. Unknown values,
+// including the legacy "garbage_truck_eta", are truck queries.
+func ParseIntentType(s string) IntentType {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, t := range intentTypes {
+		if string(t) == s {
+			return t
+		}
+	}
+	return IntentFindTrucks
+}
+
+// IntentSlots are the parameters of an intent. Only the slots relevant to the
+// intent are set.
+type IntentSlots struct {
+	// FavoriteName is the favorite to add or delete, e.g. 公司
+	FavoriteName string `json:"favorite_name"`
+	// Address is the place to save as a favorite; empty means where the user is
+	Address string `json:"address"`
+	// Sort is "best", "soonest" or "closest", as in garbage.SortMode
+	Sort string `json:"sort"`
+	// Day limits the reminders to cancel: "today", "tomorrow" or empty for any day
+	Day string `json:"day"`
+	// Stop is the stop name a reminder is for
+	Stop string `json:"stop"`
+}
+
+// greetings are answered without asking the model.
+var greetings = []string{"hi", "hello", "你好", "您好", "哈囉", "嗨"}
+
+func isGreeting(text string) bool {
+	text = strings.ToLower(strings.TrimSpace(text))
+	for _, greeting := range greetings {
+		if text == greeting {
+			return true
+		}
+	}
+	return false
+}
@@ -9,6 +9,9 @@ import (
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
+
+	"linebot-garbage-helper/internal/geo"
+	"linebot-garbage-helper/internal/utils"
 )
 
 type GeminiClient struct {
@@ -17,10 +20,11 @@ type GeminiClient struct {
 }
 
 type IntentResult struct {
+	Intent     IntentType  `json:"intent"`
+	Slots      IntentSlots `json:"slots"`
 	District   string      `json:"district"`
 	TimeWindow TimeWindow  `json:"time_window"`
 	Keywords   []string    `json:"keywords"`
-	QueryType  string      `json:"query_type"`
 }
 
 type TimeWindow struct {
@@ -45,11 +49,27 @@ func (gc *GeminiClient) Close() error {
 }
 
 func (gc *GeminiClient) AnalyzeIntent(ctx context.Context, userMessage string) (*IntentResult, error) {
+	// 打招呼不需要呼叫模型
+	if isGreeting(userMessage) {
+		return &IntentResult{Intent: IntentGreeting}, nil
+	}
+
 	model := gc.client.GenerativeModel(gc.model)
 	
-	prompt := fmt.Sprintf(`分析使用者關於垃圾車的查詢，並提取地址資訊。
+	prompt := fmt.Sprintf(`分析使用者傳給垃圾車助手的訊息，判斷意圖並提取參數與地址資訊。
 
-任務：從輸入文字中提取地址的「縣市」和「區/鄉鎮」。
+任務一：判斷意圖 intent，只能是以下其中一個：
+- find_trucks：查詢垃圾車何時、在哪裡（預設）；提到地點又問最近、最早時仍是 find_trucks，slots.sort 填排序方式
+- set_reminder：要求垃圾車來之前提醒；slots.stop 為提到的站點名稱或地址，沒有提到或只提到行政區時為空字串
+- cancel_reminder：取消提醒；slots.day 為 "today"、"tomorrow" 或空字串，slots.stop 為提到的站點名稱
+- list_favorites：查看收藏的地點
+- add_favorite：收藏地點；slots.favorite_name 為收藏名稱，slots.address 為地址，說「這裡」「目前位置」時 address 為空字串
+- delete_favorite：刪除收藏；slots.favorite_name 為收藏名稱
+- sort_results：沒有提到地點，只問哪一個站點最近、最快到或最推薦；slots.sort 為 "closest"、"soonest" 或 "best"
+- help：詢問怎麼使用、有哪些功能
+- greeting：打招呼
+
+任務二：從輸入文字中提取地址的「縣市」和「區/鄉鎮」。
 
 步驟：
 1. 識別文字中的縣市名稱（如：台北市、新北市、桃園市等）
@@ -61,29 +81,58 @@ critical_rules：
 - "新北市三重區仁義街" → district = "新北市三重區"
 - "台北市中正區重慶南路一段122號" → district = "台北市中正區"
 - "台北市" → district = "台北市"
+- 收藏名稱（如「公司」「家」）不是地址，不要放進 district
+
+任務三：將時間說法轉成台灣時間的 time_window，from、to 的格式為 "YYYY-MM-DD HH:MM"，沒有指定的一端留空字串。
+現在時間：%s
+- 「晚上七點前」→ to 為今天 19:00（已過則為明天）
+- 「七點到九點」→ from 與 to；跨過午夜時 to 為隔天，例如「晚上十一點到凌晨一點」
+- 「30分鐘內」→ from 為現在、to 為 30 分鐘後；「等一下」為一小時內；「現在」為 30 分鐘內
+- 「明天早上」→ 明天 05:00 到 10:00；「下週一」→ 下週一整天
+- 「晚上十二點前」→ to 為今天結束的午夜，也就是明天 00:00
+- 「近一點」「快一點」的「一點」是「稍微」，不是時間
 
 輸出 JSON 格式：
 {
+  "intent": "find_trucks",
+  "slots": {"favorite_name": "", "address": "", "sort": "", "day": "", "stop": ""},
   "district": "縣市+區域的完整組合",
   "time_window": {"from": "", "to": ""},
-  "keywords": ["關鍵字"],
-  "query_type": "garbage_truck_eta"
+  "keywords": ["關鍵字"]
 }
 
 範例：
 
 Input: "新北市三重區仁義街"
-Output: {"district": "新北市三重區", "time_window": {"from": "", "to": ""}, "keywords": ["新北市", "三重區", "仁義街"], "query_type": "garbage_truck_eta"}
+Output: {"intent": "find_trucks", "slots": {}, "district": "新北市三重區", "time_window": {"from": "", "to": ""}, "keywords": ["新北市", "三重區", "仁義街"]}
 
 Input: "台北市中正區重慶南路一段122號"
-Output: {"district": "台北市中正區", "time_window": {"from": "", "to": ""}, "keywords": ["台北市", "中正區", "重慶南路"], "query_type": "garbage_truck_eta"}
+Output: {"intent": "find_trucks", "slots": {}, "district": "台北市中正區", "time_window": {"from": "", "to": ""}, "keywords": ["台北市", "中正區", "重慶南路"]}
 
 Input: "台北市"
-Output: {"district": "台北市", "time_window": {"from": "", "to": ""}, "keywords": ["台北市"], "query_type": "garbage_truck_eta"}
+Output: {"intent": "find_trucks", "slots": {}, "district": "台北市", "time_window": {"from": "", "to": ""}, "keywords": ["台北市"]}
+
+Input: "幫我把這裡存成公司"
+Output: {"intent": "add_favorite", "slots": {"favorite_name": "公司", "address": ""}, "district": "", "time_window": {"from": "", "to": ""}, "keywords": ["公司"]}
+
+Input: "收藏台北市大安區新生南路二段30號叫娘家"
+Output: {"intent": "add_favorite", "slots": {"favorite_name": "娘家", "address": "台北市大安區新生南路二段30號"}, "district": "台北市大安區", "time_window": {"from": "", "to": ""}, "keywords": ["娘家"]}
+
+Input: "取消明天的提醒"
+Output: {"intent": "cancel_reminder", "slots": {"day": "tomorrow"}, "district": "", "time_window": {"from": "", "to": ""}, "keywords": ["提醒"]}
+
+Input: "哪一個最快到？"
+Output: {"intent": "sort_results", "slots": {"sort": "soonest"}, "district": "", "time_window": {"from": "", "to": ""}, "keywords": []}
+
+Input: "全家復興店最早幾點來"
+Output: {"intent": "find_trucks", "slots": {"sort": "soonest"}, "district": "", "time_window": {"from": "", "to": ""}, "keywords": ["全家復興店"]}
+
+Input: "我的收藏"
+Output: {"intent": "list_favorites", "slots": {}, "district": "", "time_window": {"from": "", "to": ""}, "keywords": []}
 
 現在分析：「%s」
 
-只回傳 JSON，不要其他文字。`, userMessage)
+只回傳 JSON，不要其他文字。`, formatPromptTime(utils.NowInTaiwan()), userMessage)
 
 	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
 	if err != nil {
@@ -94,28 +143,35 @@ Output: {"district": "台北市", "time_window": {"from": "", "to": ""}, "keywor
 		return nil, fmt.Errorf("no response from Gemini")
 	}
 	
-	responseText := fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
+	responseText := trimCodeFence(fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0]))
 	
+	// 無法解析時回傳錯誤，交給規則式分析處理
 	var result IntentResult
 	if err := json.Unmarshal([]byte(responseText), &result); err != nil {
-		return &IntentResult{
-			District:  extractDistrict(userMessage),
-			Keywords:  []string{userMessage},
-			QueryType: "garbage_truck_eta",
-			TimeWindow: TimeWindow{
-				From: "",
-				To:   "",
-			},
-		}, nil
+		return nil, fmt.Errorf("invalid intent response from Gemini: %w", err)
 	}
 	
-	if result.QueryType == "" {
-		result.QueryType = "garbage_truck_eta"
-	}
+	result.Intent = ParseIntentType(string(result.Intent))
 	
 	return &result, nil
 }
 
+// formatPromptTime writes the current time with its weekday, so the model can
+// resolve "明天" or "下週一".
+func formatPromptTime(now time.Time) string {
+	weekdays := []string{"日", "一", "二", "三", "四", "五", "六"}
+	return fmt.Sprintf("%s（星期%s）", now.Format(TimeLayout), weekdays[now.Weekday()])
+}
+
+// trimCodeFence removes the ```json fence the model sometimes wraps JSON in.
+func trimCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "```json")
+	text = strings.TrimPrefix(text, "```")
+	text = strings.TrimSuffix(text, "```")
+	return strings.TrimSpace(text)
+}
+
 func (gc *GeminiClient) ExtractLocationFromText(ctx context.Context, text string) (string, error) {
 	model := gc.client.GenerativeModel(gc.model)
 	
@@ -140,80 +196,13 @@ func (gc *GeminiClient) ExtractLocationFromText(ctx context.Context, text string
 	return location, nil
 }
 
+// extractDistrict returns the city and district mentioned in the text, e.g.
+// 台北市中正區, or just the city.
 func extractDistrict(text string) string {
-	commonDistricts := []string{
-		"台北市", "新北市", "桃園市", "台中市", "台南市", "高雄市",
-		"基隆市", "新竹市", "新竹縣", "苗栗縣", "彰化縣", "南投縣",
-		"雲林縣", "嘉義市", "嘉義縣", "屏東縣", "宜蘭縣", "花蓮縣",
-		"台東縣", "澎湖縣", "金門縣", "連江縣",
-	}
-	
-	for _, district := range commonDistricts {
-		if contains(text, district) {
-			return district
-		}
-	}
-	
-	return ""
-}
-
-func contains(text, substr string) bool {
-	return len(text) >= len(substr) && 
-		   findSubstring(text, substr) != -1
-}
-
-func findSubstring(text, substr string) int {
-	textRunes := []rune(text)
-	substrRunes := []rune(substr)
-	
-	if len(substrRunes) > len(textRunes) {
-		return -1
-	}
-	
-	for i := 0; i <= len(textRunes)-len(substrRunes); i++ {
-		found := true
-		for j := 0; j < len(substrRunes); j++ {
-			if textRunes[i+j] != substrRunes[j] {
-				found = false
-				break
-			}
-		}
-		if found {
-			return i
-		}
-	}
-	
-	return -1
+	addr := geo.ParseAddress(text)
+	return addr.City + addr.District
 }
 
 func (gc *GeminiClient) ParseTimeWindow(timeWindow TimeWindow) (time.Time, time.Time, error) {
-	var fromTime, toTime time.Time
-	var err error
-	
-	if timeWindow.From != "" {
-		fromTime, err = parseTimeString(timeWindow.From)
-		if err != nil {
-			return time.Time{}, time.Time{}, err
-		}
-	}
-	
-	if timeWindow.To != "" {
-		toTime, err = parseTimeString(timeWindow.To)
-		if err != nil {
-			return time.Time{}, time.Time{}, err
-		}
-	}
-	
-	return fromTime, toTime, nil
+	return ParseTimeWindowAt(timeWindow, utils.NowInTaiwan())
 }
-
-func parseTimeString(timeStr string) (time.Time, error) {
-	now := time.Now()
-	
-	t, err := time.Parse("15:04", timeStr)
-	if err != nil {
-		return time.Time{}, err
-	}
-	
-	return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location()), nil
-}
\ No newline at end of file
@@ -0,0 +1,177 @@
+package gemini
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/geo"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// RuleAnalyzer understands messages with fixed patterns instead of a model. It
+// is deterministic and needs no network, so it serves as the fallback when
+// Gemini is unavailable and as the analyzer in tests.
+type RuleAnalyzer struct{}
+
+func NewRuleAnalyzer() *RuleAnalyzer {
+	return &RuleAnalyzer{}
+}
+
+var (
+	politePrefixPattern = regexp.MustCompile(`^(?:請|麻煩|可以)?(?:幫我|幫忙|替我)?`)
+
+	helpPattern           = regexp.MustCompile(`(?i)^(?:help|說明|幫助|使用說明|教學)$|怎麼用|如何使用|有哪些功能|可以做什麼`)
+	cancelReminderPattern = regexp.MustCompile(`(?:取消|不要|刪除|刪掉|關掉|關閉).*(?:提醒|通知)`)
+	setReminderPattern    = regexp.MustCompile(`提醒我|通知我|(?:設定?|設個|開啟)提醒`)
+	// 「全家復興店的垃圾車來之前提醒我」中站點以外的字詞
+	reminderFillerPattern = regexp.MustCompile(`(?:快|會|要)?(?:來|到|抵達)(?:之前|以前|前)?|之前|以前|的?時候|時$`)
+	listFavoritesPattern  = regexp.MustCompile(`^(?:我的|查看|看|列出|顯示)?(?:收藏|最愛)(?:清單|列表|地點|的地點|有哪些)?$|收藏了(?:哪些|什麼)`)
+	// 「刪除收藏 公司」「刪掉公司」「把公司從收藏刪掉」
+	deleteFavoritePattern = regexp.MustCompile(`^(?:(?:刪除|刪掉|移除)(?:收藏|最愛)?的?\s*(.+?)|把(.+?)(?:從收藏|從最愛)?(?:刪除|刪掉|移除)(?:掉)?)$`)
+	// 「把這裡存成公司」「收藏台北市…叫娘家」
+	saveAsPattern = regexp.MustCompile(`^(?:把)?(.+?)(?:存成|存為|收藏成|收藏為|儲存為)(.+)$`)
+	// 「把這裡設成公司」只有前面是目前位置或地址時才是收藏，「提醒設成七點」不是
+	setAsPattern       = regexp.MustCompile(`^(?:把)?(.+?)(?:設為|設成|記成)(.+)$`)
+	saveNamedPattern   = regexp.MustCompile(`^(?:收藏|儲存|存)(.+?)(?:叫做|叫|名稱為|名為)(.+)$`)
+	currentPlaceWords  = regexp.MustCompile(`^(?:這裡|這邊|這個位置|這個地點|目前位置|目前的位置|現在的位置|我的位置|現在位置)$`)
+	sortSoonestPattern = regexp.MustCompile(`最快|最早|馬上.*來|快到了`)
+	sortClosestPattern = regexp.MustCompile(`最近的|哪(?:個|一個|裡)最近|離我最近|最近是`)
+	sortBestPattern    = regexp.MustCompile(`推薦|最好|最適合|最方便`)
+	// 排序問句中不是地點的字詞
+	sortQuestionPattern = regexp.MustCompile(`哪一?(?:個|站|班|邊)?|是|離我|最近|到|來|的`)
+
+	// 地點以外常見的字詞，抽取地點時去掉
+	locationFillerPattern = regexp.MustCompile(`請問|我(?:現在|目前)?(?:住)?在|我家在|附近|一帶|有?的?垃圾車|倒垃圾|丟垃圾|什麼時候|幾點|會來|會到|來嗎|在哪裡?|哪裡|有沒有|有嗎|呢|嗎|[?？!！。，,]`)
+)
+
+func (ra *RuleAnalyzer) AnalyzeIntent(ctx context.Context, userMessage string) (*IntentResult, error) {
+	text := strings.TrimSpace(userMessage)
+	result := &IntentResult{
+		Intent:     IntentFindTrucks,
+		District:   extractDistrict(text),
+		TimeWindow: ExtractTimeWindow(text, utils.NowInTaiwan()),
+		Keywords:   []string{text},
+	}
+
+	command := politePrefixPattern.ReplaceAllString(text, "")
+	switch {
+	case isGreeting(text):
+		result.Intent = IntentGreeting
+	case helpPattern.MatchString(command):
+		result.Intent = IntentHelp
+	case cancelReminderPattern.MatchString(command):
+		result.Intent = IntentCancelReminder
+		// 「明天的提醒」指提醒的日期，不是查詢的時間範圍
+		result.Slots.Day = reminderDay(command)
+		result.TimeWindow = TimeWindow{}
+	case setReminderPattern.MatchString(command):
+		result.Intent = IntentSetReminder
+		result.Slots.Stop = ra.reminderStop(command)
+	case listFavoritesPattern.MatchString(command):
+		result.Intent = IntentListFavorites
+	case deleteFavoritePattern.MatchString(command):
+		m := deleteFavoritePattern.FindStringSubmatch(command)
+		result.Intent = IntentDeleteFavorite
+		result.Slots.FavoriteName = strings.TrimSpace(m[1] + m[2])
+	case saveAsPattern.MatchString(command) || saveNamedPattern.MatchString(command) || isSetAsPlace(command):
+		m := saveAsPattern.FindStringSubmatch(command)
+		if m == nil {
+			m = saveNamedPattern.FindStringSubmatch(command)
+		}
+		if m == nil {
+			m = setAsPattern.FindStringSubmatch(command)
+		}
+		result.Intent = IntentAddFavorite
+		result.Slots.FavoriteName = strings.TrimRight(strings.TrimSpace(m[2]), "了吧喔")
+		if place := strings.TrimSpace(m[1]); !currentPlaceWords.MatchString(place) {
+			result.Slots.Address = place
+		}
+		// 收藏名稱不是要查詢的地區
+		result.District = extractDistrict(result.Slots.Address)
+	case sortSoonestPattern.MatchString(command):
+		result.Slots.Sort = "soonest"
+	case sortClosestPattern.MatchString(command):
+		result.Slots.Sort = "closest"
+	case sortBestPattern.MatchString(command):
+		result.Slots.Sort = "best"
+	}
+
+	// 「全家復興店最早幾點來」仍是查詢，排序只是附帶條件；沒有地點時才是排序目前的結果
+	if result.Slots.Sort != "" && result.District == "" && ra.placeIn(command) == "" {
+		result.Intent = IntentSortResults
+	}
+
+	return result, nil
+}
+
+// reminderStop returns the stop named in a reminder request, e.g. "全家復興店"
+// in "全家復興店的垃圾車來之前提醒我", or "" when the message names no stop or
+// only an area.
+func (ra *RuleAnalyzer) reminderStop(text string) string {
+	text = setReminderPattern.ReplaceAllString(text, "")
+	text = reminderFillerPattern.ReplaceAllString(text, "")
+	place, _ := ra.ExtractLocationFromText(context.Background(), text)
+	if place == geo.ParseAddress(place).Area() {
+		return ""
+	}
+	return place
+}
+
+// isSetAsPlace reports whether a 設成/設為/記成 sentence names the current place
+// or an address before the verb, e.g. "把這裡設成公司".
+func isSetAsPlace(text string) bool {
+	m := setAsPattern.FindStringSubmatch(text)
+	if m == nil {
+		return false
+	}
+	place := strings.TrimSpace(m[1])
+	return currentPlaceWords.MatchString(place) || geo.ParseAddress(place).Road != ""
+}
+
+// placeIn returns the place named in a sort question, e.g. "全家復興店" in
+// "全家復興店最早幾點來", or "" for "哪一個最快到".
+func (ra *RuleAnalyzer) placeIn(text string) string {
+	for _, pattern := range []*regexp.Regexp{sortSoonestPattern, sortClosestPattern, sortBestPattern, sortQuestionPattern} {
+		text = pattern.ReplaceAllString(text, "")
+	}
+	place, _ := ra.ExtractLocationFromText(context.Background(), text)
+	return place
+}
+
+// ExtractLocationFromText returns the address in the text or, when there is
+// none, what is left after removing the question around the place name.
+func (ra *RuleAnalyzer) ExtractLocationFromText(ctx context.Context, text string) (string, error) {
+	addr := geo.ParseAddress(text)
+	if addr.Road != "" {
+		return addr.String(), nil
+	}
+	if area := addr.Area(); area != "" {
+		return area, nil
+	}
+
+	location := text
+	for _, pattern := range []*regexp.Regexp{durationPattern, timePattern, dayPattern, periodPattern, soonPattern, laterPattern, locationFillerPattern} {
+		location = pattern.ReplaceAllString(location, "")
+	}
+	location = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(location, "前"), "後"))
+	if len([]rune(location)) < 2 {
+		return "", nil
+	}
+	return location, nil
+}
+
+func (ra *RuleAnalyzer) ParseTimeWindow(timeWindow TimeWindow) (time.Time, time.Time, error) {
+	return ParseTimeWindowAt(timeWindow, utils.NowInTaiwan())
+}
+
+func reminderDay(text string) string {
+	switch {
+	case strings.Contains(text, "今天") || strings.Contains(text, "今晚"):
+		return "today"
+	case strings.Contains(text, "明天") || strings.Contains(text, "明晚"):
+		return "tomorrow"
+	}
+	return ""
+}
@@ -0,0 +1,458 @@
+package gemini
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/utils"
+)
+
+// TimeLayout is how resolved times are written in a TimeWindow, in Taiwan time.
+const TimeLayout = "2006-01-02 15:04"
+
+const (
+	// soonWindow is how far ahead "現在" looks.
+	soonWindow = 30 * time.Minute
+	// laterWindow is how far ahead "等一下" looks.
+	laterWindow = time.Hour
+)
+
+// dayPeriod is a part of the day, in hours from midnight.
+type dayPeriod struct {
+	start, end int
+}
+
+var dayPeriods = map[string]dayPeriod{
+	"凌晨": {0, 5},
+	"早上": {5, 10},
+	"上午": {6, 12},
+	"中午": {11, 14},
+	"下午": {12, 18},
+	"傍晚": {16, 19},
+	"晚上": {18, 24},
+}
+
+var (
+	// 時間：「晚上七點半」「19:30」「下午3點15分」
+	timePattern       = regexp.MustCompile(`(早上|上午|中午|下午|傍晚|晚上|凌晨)?\s*(\d{1,2}|[零一二兩三四五六七八九十]+)\s*(?:點|時|:|：)\s*(半|\d{1,2}|[零一二三四五六七八九十]+)?\s*分?`)
+	timeBeforePattern = regexp.MustCompile(`^\s*(?:以前|之前|前)`)
+	timeAfterPattern  = regexp.MustCompile(`^\s*(?:以後|之後|過後|後)`)
+	timeRangePattern  = regexp.MustCompile(`^\s*(?:到|至|~|～|-|－)\s*$`)
+	// 「七點前」「七點到九點」：國字的時間前後有這些字時才一定是時刻
+	clockQualifierPattern = regexp.MustCompile(`^\s*(?:以前|之前|前|以後|之後|過後|後|到|至|~|～|-|－)`)
+	clockRangeEndPattern  = regexp.MustCompile(`(?:到|至|~|～|-|－)\s*$`)
+	// 「九點的垃圾車」「十點有車嗎」：點後面接這些字或句子結束時也是時刻
+	clockFollowerPattern = regexp.MustCompile(`^\s*(?:的|有|會|來|[?？!！。]*\s*$)`)
+	// 「近一點」「快一點」的一點是「稍微」，不是一點鐘
+	aBitPattern = regexp.MustCompile(`[近快早晚多少]\s*$`)
+
+	// 「30分鐘內」「半小時後」「兩個小時內」「一個半小時內」
+	durationPattern = regexp.MustCompile(`(\d+|[一二兩三四五六七八九十]+|半)\s*個?\s*(半)?\s*(分鐘|小時|鐘頭)\s*(內|以內|之內|後|以後|之後)`)
+	soonPattern     = regexp.MustCompile(`現在|馬上|立刻`)
+	laterPattern    = regexp.MustCompile(`等一下|等下|等等|待會|一會兒`)
+	// 「明天」「下週一」「星期六」；今晚、明早、明晚同時指定時段
+	dayPattern    = regexp.MustCompile(`今天|今日|今晚|明天|明日|明早|明晚|後天|(下個?)?(?:週|周|星期|禮拜)([一二三四五六日天])`)
+	periodPattern = regexp.MustCompile(`凌晨|早上|上午|中午|下午|傍晚|晚上`)
+)
+
+// ExtractTimeWindow resolves the time expression in the text, e.g. "晚上七點前",
+// "30分鐘內", "明天早上" or "下週一", to a window in Taiwan time relative to
+// now. Times are written with TimeLayout; the window is empty when the text has
+// no time expression.
+func ExtractTimeWindow(text string, now time.Time) TimeWindow {
+	from, to := resolveTimeExpression(text, utils.ToTaiwan(now))
+	return formatWindow(from, to)
+}
+
+// ParseTimeWindowAt converts a window to times in Taiwan time. Each end may be
+// written with TimeLayout, as a clock time "15:04", or as a time expression.
+// Clock times refer to their next occurrence: a window whose end has passed
+// moves to tomorrow, and an end before the start is on the following day.
+func ParseTimeWindowAt(timeWindow TimeWindow, now time.Time) (time.Time, time.Time, error) {
+	now = utils.ToTaiwan(now)
+
+	from, fromClock, err := parseWindowEnd(timeWindow.From, now, false)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, toClock, err := parseWindowEnd(timeWindow.To, now, true)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	// 只有時刻時，沿用另一端的日期
+	if fromClock && !to.IsZero() && !toClock {
+		from = onDate(to, from)
+	}
+	if toClock && !from.IsZero() && !fromClock {
+		to = onDate(from, to)
+	}
+
+	if !from.IsZero() && !to.IsZero() && to.Before(from) && toClock {
+		to = to.AddDate(0, 0, 1)
+	}
+	// 時段已經過了，改查明天同一時段；只有起點的時段仍在進行中，不需要調整
+	if toClock && to.Before(now) {
+		to = to.AddDate(0, 0, 1)
+		if fromClock {
+			from = from.AddDate(0, 0, 1)
+		}
+	}
+
+	return from, to, nil
+}
+
+// parseWindowEnd parses one end of a window, reporting whether it was a bare
+// clock time anchored to today.
+func parseWindowEnd(s string, now time.Time, isEnd bool) (time.Time, bool, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false, nil
+	}
+
+	if t, err := time.ParseInLocation(TimeLayout, s, now.Location()); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse("15:04", s); err == nil {
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location()), true, nil
+	}
+
+	from, to := resolveTimeExpression(s, now)
+	switch {
+	case isEnd && !to.IsZero():
+		return to, false, nil
+	case !from.IsZero():
+		return from, false, nil
+	case !to.IsZero():
+		return to, false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("unrecognized time: %s", s)
+}
+
+// resolveTimeExpression returns the window the text refers to. Either end is
+// zero when it is open.
+func resolveTimeExpression(text string, now time.Time) (time.Time, time.Time) {
+	if m := durationPattern.FindStringSubmatch(text); m != nil {
+		if d := parseDuration(m[1], m[2] != "", m[3]); d > 0 {
+			if strings.HasSuffix(m[4], "內") {
+				return now, now.Add(d)
+			}
+			return now.Add(d), time.Time{}
+		}
+	}
+	if soonPattern.MatchString(text) {
+		return now, now.Add(soonWindow)
+	}
+	if laterPattern.MatchString(text) {
+		return now, now.Add(laterWindow)
+	}
+
+	today := startOfDay(now)
+	day, period, hasDay := dayFor(text, today)
+	if p := periodPattern.FindString(text); p != "" {
+		period = p
+	}
+
+	if matches := clockMatches(text, period); len(matches) > 0 {
+		// 沒有指定日期時，沒有時段的鐘點取現在之後最近的一次
+		var after time.Time
+		if !hasDay {
+			after = now
+		}
+		if from, to, ok := clockWindow(text, matches, day, period, after); ok {
+			// 「禮拜三早上七點前」從禮拜三開始，不包含之前的日子
+			if hasDay && from.IsZero() && day.After(today) {
+				from = day
+			}
+			// 沒有指定日期時，已經過去的時間指的是明天；只有起點的時段仍在進行中
+			if !hasDay {
+				switch {
+				case !to.IsZero() && to.Before(now):
+					to = to.AddDate(0, 0, 1)
+					if !from.IsZero() {
+						from = from.AddDate(0, 0, 1)
+					}
+				case to.IsZero() && !from.IsZero() && from.Before(now) && !timeAfterPattern.MatchString(text[matches[0][1]:]):
+					from = from.AddDate(0, 0, 1)
+				}
+			}
+			return from, to
+		}
+	}
+
+	if p, ok := dayPeriods[period]; ok {
+		from := day.Add(time.Duration(p.start) * time.Hour)
+		to := day.Add(time.Duration(p.end) * time.Hour)
+		if !hasDay && !to.After(now) {
+			from, to = from.AddDate(0, 0, 1), to.AddDate(0, 0, 1)
+		}
+		if from.Before(now) {
+			from = now
+		}
+		return from, to
+	}
+
+	if hasDay {
+		from := day
+		if from.Before(now) {
+			from = now
+		}
+		return from, day.AddDate(0, 0, 1)
+	}
+
+	return time.Time{}, time.Time{}
+}
+
+// dayFor finds the day named in the text, with the part of the day it implies
+// (今晚 is 晚上). Without one the day is today.
+func dayFor(text string, today time.Time) (time.Time, string, bool) {
+	m := dayPattern.FindStringSubmatch(text)
+	if m == nil {
+		return today, "", false
+	}
+
+	switch m[0] {
+	case "今天", "今日":
+		return today, "", true
+	case "今晚":
+		return today, "晚上", true
+	case "明天", "明日":
+		return today.AddDate(0, 0, 1), "", true
+	case "明早":
+		return today.AddDate(0, 0, 1), "早上", true
+	case "明晚":
+		return today.AddDate(0, 0, 1), "晚上", true
+	case "後天":
+		return today.AddDate(0, 0, 2), "", true
+	}
+
+	// 一週從週一開始：「週三」是這週三（已過則為下週三），「下週三」是下一週的週三
+	target := strings.Index("一二三四五六日天", m[2]) / len("一")
+	if target > 6 {
+		target = 6
+	}
+	current := (int(today.Weekday()) + 6) % 7
+	days := (target - current + 7) % 7
+	if m[1] != "" {
+		days = 7 - current + target
+	}
+	return today.AddDate(0, 0, days), "", true
+}
+
+// clockMatches returns up to two timePattern matches that are clock times. A
+// Chinese numeral with 點 alone often is not one, as in 「近一點的垃圾車」, so it
+// needs a part of the day, minutes, a 前, 後 or 到 next to it, or, for hours
+// other than 一, a 的, 有, 會 or 來 or the end of the text after it.
+func clockMatches(text, period string) [][]int {
+	var clocks [][]int
+	for _, m := range timePattern.FindAllStringSubmatchIndex(text, -1) {
+		if !isClockTime(text, m, period) {
+			continue
+		}
+		clocks = append(clocks, m)
+		if len(clocks) == 2 {
+			break
+		}
+	}
+	return clocks
+}
+
+func isClockTime(text string, m []int, period string) bool {
+	hasPeriod := m[2] >= 0
+	hasMinute := m[6] >= 0
+	hour := text[m[4]:m[5]]
+
+	if hour == "一" && !hasPeriod && !hasMinute && aBitPattern.MatchString(text[:m[0]]) {
+		return false
+	}
+	if hasPeriod || hasMinute || period != "" {
+		return true
+	}
+	if _, err := strconv.Atoi(hour); err == nil {
+		return true
+	}
+	// 單獨的「一點」仍可能是「稍微」，如「一點的垃圾車」，其他鐘點看後面的字
+	if hour != "一" && strings.HasSuffix(strings.TrimSpace(text[m[0]:m[1]]), "點") && clockFollowerPattern.MatchString(text[m[1]:]) {
+		return true
+	}
+	return clockQualifierPattern.MatchString(text[m[1]:]) || clockRangeEndPattern.MatchString(text[:m[0]])
+}
+
+// clockWindow builds the window from the first one or two clock times: "七點到
+// 九點" is a range, "七點前" ends at seven, and "七點" or "七點後" starts there.
+// When now is set, an hour from 1 to 11 without a part of the day is its next
+// occurrence after now, so at 20:30 "九點" is 21:00 rather than 09:00 tomorrow.
+func clockWindow(text string, matches [][]int, day time.Time, period string, now time.Time) (time.Time, time.Time, bool) {
+	first, firstPeriod, ok := clockOn(text, matches[0], day, period)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	rest := text[matches[0][1]:]
+
+	// 「七點後」可以是進行中的時段，維持原本的上午時間
+	if firstPeriod == "" && !now.IsZero() && first.Hour() >= 1 && first.Hour() <= 11 && !timeAfterPattern.MatchString(rest) {
+		first, firstPeriod = nextOccurrence(first, now)
+	}
+
+	if len(matches) == 2 && timeRangePattern.MatchString(text[matches[0][1]:matches[1][0]]) {
+		// 「晚上七點到九點」的第二個時間沿用「晚上」；早於起點則是隔天，例如「晚上十一點到凌晨一點」
+		if second, _, ok := clockOn(text, matches[1], day, firstPeriod); ok {
+			if second.Before(first) {
+				second = second.AddDate(0, 0, 1)
+			}
+			return first, second, true
+		}
+	}
+	if timeBeforePattern.MatchString(rest) {
+		return time.Time{}, first, true
+	}
+	return first, time.Time{}, true
+}
+
+// nextOccurrence returns the first of the morning time am, the same time in the
+// afternoon and am tomorrow that is not before now, with the part of the day it
+// falls in.
+func nextOccurrence(am, now time.Time) (time.Time, string) {
+	if !am.Before(now) {
+		return am, "早上"
+	}
+	if pm := am.Add(12 * time.Hour); !pm.Before(now) {
+		return pm, "下午"
+	}
+	return am.AddDate(0, 0, 1), "早上"
+}
+
+// clockOn resolves one timePattern match on the day, returning it with the
+// part of the day it used.
+func clockOn(text string, match []int, day time.Time, defaultPeriod string) (time.Time, string, bool) {
+	group := func(i int) string {
+		if match[2*i] < 0 {
+			return ""
+		}
+		return text[match[2*i]:match[2*i+1]]
+	}
+
+	period := group(1)
+	if period == "" {
+		period = defaultPeriod
+	}
+	hour := chineseNumber(group(2))
+	minute := 0
+	switch m := group(3); m {
+	case "":
+	case "半":
+		minute = 30
+	default:
+		minute = chineseNumber(m)
+	}
+
+	switch period {
+	case "傍晚", "晚上":
+		// 「晚上十二點」是當天結束的午夜
+		if hour <= 12 {
+			hour += 12
+		}
+	case "下午":
+		if hour < 12 {
+			hour += 12
+		}
+	case "中午":
+		if hour < 6 {
+			hour += 12
+		}
+	case "凌晨", "早上", "上午":
+		if hour == 12 {
+			hour = 0
+		}
+	}
+
+	if hour < 0 || hour > 24 || minute < 0 || minute > 59 || (hour == 24 && minute > 0) {
+		return time.Time{}, period, false
+	}
+	return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute), period, true
+}
+
+// parseDuration converts an amount of minutes or hours to a duration; half adds
+// half a unit, as in 「一個半小時」.
+func parseDuration(amount string, half bool, unit string) time.Duration {
+	if amount == "半" {
+		if unit == "分鐘" || half {
+			return 0
+		}
+		return 30 * time.Minute
+	}
+	n := chineseNumber(amount)
+	if n <= 0 {
+		return 0
+	}
+
+	d := time.Duration(n) * time.Hour
+	if unit == "分鐘" {
+		d = time.Duration(n) * time.Minute
+	}
+	if half {
+		d += d / time.Duration(n) / 2
+	}
+	return d
+}
+
+// chineseNumber parses digits or Chinese numerals below 100, returning -1 when
+// the text is neither.
+func chineseNumber(text string) int {
+	if n, err := strconv.Atoi(text); err == nil {
+		return n
+	}
+
+	digits := map[rune]int{'零': 0, '一': 1, '二': 2, '兩': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	runes := []rune(text)
+	n, i := 0, 0
+	if len(runes) > 0 && runes[0] != '十' {
+		d, ok := digits[runes[0]]
+		if !ok {
+			return -1
+		}
+		n, i = d, 1
+	}
+	if i < len(runes) && runes[i] == '十' {
+		if i == 0 {
+			n = 1
+		}
+		n *= 10
+		i++
+		if i < len(runes) {
+			d, ok := digits[runes[i]]
+			if !ok {
+				return -1
+			}
+			n += d
+			i++
+		}
+	}
+	if i != len(runes) || len(runes) == 0 {
+		return -1
+	}
+	return n
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// onDate moves the clock time of t to the date of day.
+func onDate(day, t time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location())
+}
+
+func formatWindow(from, to time.Time) TimeWindow {
+	var window TimeWindow
+	if !from.IsZero() {
+		window.From = from.Format(TimeLayout)
+	}
+	if !to.IsZero() {
+		window.To = to.Format(TimeLayout)
+	}
+	return window
+}
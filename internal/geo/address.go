@@ -0,0 +1,224 @@
+package geo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Cities lists Taiwan's cities and counties.
+var Cities = []string{
+	"台北市", "新北市", "桃園市", "台中市", "台南市", "高雄市",
+	"基隆市", "新竹市", "嘉義市", "新竹縣", "苗栗縣", "彰化縣",
+	"南投縣", "雲林縣", "嘉義縣", "屏東縣", "宜蘭縣", "花蓮縣",
+	"台東縣", "澎湖縣", "金門縣", "連江縣",
+}
+
+var (
+	postalCodePattern = regexp.MustCompile(`^\d{3}(\d{2,3})?$`)
+	districtPattern   = regexp.MustCompile(`^\p{Han}{1,3}?[區鄉鎮市]`)
+	villagePattern    = regexp.MustCompile(`^\p{Han}{1,3}?[里村]`)
+	neighborPattern   = regexp.MustCompile(`^\d+鄰`)
+	roadPattern       = regexp.MustCompile(`^\p{Han}+?(大道|路|街)`)
+	sectionPattern    = regexp.MustCompile(`^([一二三四五六七八九十]+|\d+)段`)
+	lanePattern       = regexp.MustCompile(`^(\d+)巷口?`)
+	alleyPattern      = regexp.MustCompile(`^(\d+)弄口?`)
+	numberPattern     = regexp.MustCompile(`^(\d+(?:[之-]\d+)?)號(?:之(\d+))?`)
+	floorPattern      = regexp.MustCompile(`^(?:(B\d+)(?:樓|F)?|(\d+|[一二三四五六七八九十]+)(?:樓|F))(?:之\d+)?`)
+)
+
+// Address is a Taiwanese address split into its components. Digits are
+// normalized to half-width and 臺 to 台.
+type Address struct {
+	City     string
+	District string
+	// Village is the 里 or 村
+	Village string
+	Road    string
+	Section int
+	Lane    string
+	Alley   string
+	Number  string
+	Floor   string
+	// Rest is the text that is not an address component, e.g. a landmark name
+	Rest string
+}
+
+// ParseAddress splits free text such as "臺北市大安區新生南路２段30巷5號3樓" into
+// its components. Components that are missing are left empty; text before the
+// city and anything not recognized is kept in Rest.
+func ParseAddress(text string) Address {
+	var addr Address
+	s := NormalizeAddressText(text)
+
+	// Text before the city, e.g. "我住在", is not part of the address
+	var prefix string
+	for _, city := range Cities {
+		if i := strings.Index(s, city); i >= 0 {
+			prefix, s = s[:i], s[i:]
+			break
+		}
+	}
+	// 郵遞區號
+	if postalCodePattern.MatchString(prefix) {
+		prefix = ""
+	}
+
+	for _, city := range Cities {
+		short := strings.TrimSuffix(strings.TrimSuffix(city, "市"), "縣")
+		if strings.HasPrefix(s, city) {
+			addr.City, s = city, s[len(city):]
+			break
+		}
+		// 省略「市」「縣」的寫法，例如「台北大安區」
+		if strings.HasPrefix(s, short) && districtPattern.MatchString(s[len(short):]) {
+			addr.City, s = city, s[len(short):]
+			break
+		}
+	}
+
+	if match := districtPattern.FindString(s); match != "" && (addr.City != "" || strings.HasSuffix(match, "區")) {
+		addr.District, s = match, s[len(match):]
+	}
+	// 「中里路」的「中里」是路名的一部分，不是里名
+	if match := villagePattern.FindString(s); match != "" && !isRoadSuffix(s[len(match):]) {
+		addr.Village, s = match, s[len(match):]
+	}
+	s = neighborPattern.ReplaceAllString(s, "")
+
+	if match := roadPattern.FindString(s); match != "" {
+		addr.Road, s = match, s[len(match):]
+	}
+	if m := sectionPattern.FindStringSubmatch(s); m != nil {
+		addr.Section = parseChineseNumber(m[1])
+		s = s[len(m[0]):]
+	}
+	if m := lanePattern.FindStringSubmatch(s); m != nil {
+		addr.Lane, s = m[1], s[len(m[0]):]
+	}
+	if m := alleyPattern.FindStringSubmatch(s); m != nil {
+		addr.Alley, s = m[1], s[len(m[0]):]
+	}
+	if m := numberPattern.FindStringSubmatch(s); m != nil {
+		addr.Number = strings.ReplaceAll(m[1], "-", "之")
+		if m[2] != "" {
+			addr.Number += "之" + m[2]
+		}
+		s = s[len(m[0]):]
+	}
+	if m := floorPattern.FindStringSubmatch(s); m != nil {
+		addr.Floor = m[1]
+		if m[2] != "" {
+			addr.Floor = strconv.Itoa(parseChineseNumber(m[2]))
+		}
+		s = s[len(m[0]):]
+	}
+
+	addr.Rest = strings.TrimSpace(prefix + s)
+	return addr
+}
+
+// NormalizeAddressText converts full-width characters to half-width, 臺 to 台,
+// and drops whitespace and separators.
+func NormalizeAddressText(text string) string {
+	text = strings.ReplaceAll(text, "臺", "台")
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r >= '！' && r <= '～':
+			r = r - '！' + '!'
+		case r == '　':
+			r = ' '
+		}
+		switch r {
+		case ' ', '\t', '\n', ',', '，', '、':
+			return -1
+		}
+		return r
+	}, text)
+	return strings.TrimPrefix(text, "台灣")
+}
+
+// Area returns the city, district and 里, e.g. 台北市大安區龍安里.
+func (a Address) Area() string {
+	return a.City + a.District + a.Village
+}
+
+// Street returns the road down to the house number, e.g. 新生南路二段30巷5號.
+func (a Address) Street() string {
+	var b strings.Builder
+	b.WriteString(a.Road)
+	if a.Section > 0 {
+		b.WriteString(formatChineseNumber(a.Section) + "段")
+	}
+	if a.Lane != "" {
+		b.WriteString(a.Lane + "巷")
+	}
+	if a.Alley != "" {
+		b.WriteString(a.Alley + "弄")
+	}
+	if a.Number != "" {
+		b.WriteString(a.Number + "號")
+	}
+	return b.String()
+}
+
+// String formats the address for geocoding. The 里, floor and unrecognized text
+// are left out since they do not change the location on a map.
+func (a Address) String() string {
+	return a.City + a.District + a.Street()
+}
+
+// Key identifies the place the address refers to, for de-duplication. It is
+// empty when the address has no street.
+func (a Address) Key() string {
+	if a.Road == "" {
+		return ""
+	}
+	return a.String()
+}
+
+func isRoadSuffix(s string) bool {
+	return strings.HasPrefix(s, "路") || strings.HasPrefix(s, "街") || strings.HasPrefix(s, "大道")
+}
+
+// parseChineseNumber parses digits or Chinese numerals up to 99, returning 0
+// when the text is neither.
+func parseChineseNumber(text string) int {
+	if n, err := strconv.Atoi(text); err == nil {
+		return n
+	}
+
+	digits := map[rune]int{'一': 1, '二': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	runes := []rune(text)
+	switch {
+	case len(runes) == 1 && runes[0] == '十':
+		return 10
+	case len(runes) == 1:
+		return digits[runes[0]]
+	case len(runes) == 2 && runes[0] == '十':
+		return 10 + digits[runes[1]]
+	case len(runes) == 2 && runes[1] == '十':
+		return digits[runes[0]] * 10
+	case len(runes) == 3 && runes[1] == '十':
+		return digits[runes[0]]*10 + digits[runes[2]]
+	}
+	return 0
+}
+
+// formatChineseNumber writes section numbers the way addresses do (2 → 二).
+func formatChineseNumber(n int) string {
+	numerals := []string{"", "一", "二", "三", "四", "五", "六", "七", "八", "九"}
+	switch {
+	case n <= 0 || n >= 100:
+		return fmt.Sprint(n)
+	case n < 10:
+		return numerals[n]
+	case n == 10:
+		return "十"
+	case n < 20:
+		return "十" + numerals[n%10]
+	default:
+		return numerals[n/10] + "十" + numerals[n%10]
+	}
+}
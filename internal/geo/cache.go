@@ -0,0 +1,225 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultGeocodeTTL is how long an address lookup is cached by default.
+	DefaultGeocodeTTL = 30 * 24 * time.Hour
+	// DefaultReverseGeocodeTTL is how long a coordinate lookup is cached by default.
+	DefaultReverseGeocodeTTL = 7 * 24 * time.Hour
+
+	// maxCacheEntries bounds the in-memory cache
+	maxCacheEntries = 10000
+	// coordinatePrecision is the number of decimals coordinates are rounded to
+	// for reverse geocoding keys, about 11 m
+	coordinatePrecision = 4
+)
+
+// CacheStore persists cached lookups so they survive restarts and are shared
+// between instances. GetGeocode returns nil when the key is not stored.
+type CacheStore interface {
+	GetGeocode(ctx context.Context, key string) (*Location, time.Time, error)
+	SaveGeocode(ctx context.Context, key string, location *Location, expiresAt time.Time) error
+}
+
+// CacheConfig configures a GeocodeCache. Zero TTLs use the defaults.
+type CacheConfig struct {
+	TTL        time.Duration
+	ReverseTTL time.Duration
+	// Store optionally persists entries behind the in-memory cache
+	Store CacheStore
+}
+
+// CacheStats counts cache outcomes since the cache was created.
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	StoreHits uint64 `json:"storeHits"`
+	Misses    uint64 `json:"misses"`
+	Coalesced uint64 `json:"coalesced"`
+	Entries   int    `json:"entries"`
+}
+
+// GeocodeCache caches geocoding results in memory, and optionally in a
+// CacheStore, and coalesces concurrent lookups of the same key into one request.
+type GeocodeCache struct {
+	config CacheConfig
+
+	mu       sync.Mutex
+	entries  map[string]cacheEntry
+	inflight map[string]*cacheCall
+
+	hits      atomic.Uint64
+	storeHits atomic.Uint64
+	misses    atomic.Uint64
+	coalesced atomic.Uint64
+}
+
+type cacheEntry struct {
+	location  Location
+	expiresAt time.Time
+}
+
+type cacheCall struct {
+	done     chan struct{}
+	location *Location
+	err      error
+}
+
+func NewGeocodeCache(config CacheConfig) *GeocodeCache {
+	if config.TTL <= 0 {
+		config.TTL = DefaultGeocodeTTL
+	}
+	if config.ReverseTTL <= 0 {
+		config.ReverseTTL = DefaultReverseGeocodeTTL
+	}
+
+	return &GeocodeCache{
+		config:   config,
+		entries:  make(map[string]cacheEntry),
+		inflight: make(map[string]*cacheCall),
+	}
+}
+
+// AddressKey normalizes an address so spacing, case, 臺/台 and full-width
+// digits do not produce separate entries.
+func AddressKey(address string) string {
+	address = strings.ReplaceAll(address, "臺", "台")
+	address = strings.Map(func(r rune) rune {
+		if r >= '０' && r <= '９' {
+			return r - '０' + '0'
+		}
+		return r
+	}, address)
+	return "addr:" + strings.ToLower(strings.Join(strings.Fields(address), " "))
+}
+
+// CoordinateKey rounds coordinates so nearby shared locations share an entry.
+func CoordinateKey(lat, lng float64) string {
+	return fmt.Sprintf("latlng:%.*f,%.*f", coordinatePrecision, lat, coordinatePrecision, lng)
+}
+
+// Stats returns the cache counters.
+func (c *GeocodeCache) Stats() CacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		StoreHits: c.storeHits.Load(),
+		Misses:    c.misses.Load(),
+		Coalesced: c.coalesced.Load(),
+		Entries:   entries,
+	}
+}
+
+// Do returns the cached location for key, or calls fetch and caches its result
+// for ttl. Concurrent calls with the same key wait for the first one. Errors are
+// not cached.
+func (c *GeocodeCache) Do(ctx context.Context, key string, ttl time.Duration, fetch func(context.Context) (*Location, error)) (*Location, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && now.Before(entry.expiresAt) {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		location := entry.location
+		return &location, nil
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		c.coalesced.Add(1)
+		select {
+		case <-call.done:
+			return copyLocation(call.location), call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	call.location, call.err = c.load(ctx, key, ttl, fetch)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	close(call.done)
+
+	return copyLocation(call.location), call.err
+}
+
+func (c *GeocodeCache) load(ctx context.Context, key string, ttl time.Duration, fetch func(context.Context) (*Location, error)) (*Location, error) {
+	if c.config.Store != nil {
+		location, expiresAt, err := c.config.Store.GetGeocode(ctx, key)
+		if err != nil {
+			log.Printf("Error reading geocode cache entry %s: %v", key, err)
+		} else if location != nil && time.Now().Before(expiresAt) {
+			c.storeHits.Add(1)
+			c.put(key, *location, expiresAt)
+			return location, nil
+		}
+	}
+
+	c.misses.Add(1)
+	location, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	c.put(key, *location, expiresAt)
+
+	if c.config.Store != nil {
+		if err := c.config.Store.SaveGeocode(ctx, key, location, expiresAt); err != nil {
+			log.Printf("Error saving geocode cache entry %s: %v", key, err)
+		}
+	}
+
+	return location, nil
+}
+
+func (c *GeocodeCache) put(key string, location Location, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCacheEntries {
+		c.evictLocked()
+	}
+	c.entries[key] = cacheEntry{location: location, expiresAt: expiresAt}
+}
+
+// evictLocked drops expired entries, and when none have expired, an arbitrary
+// tenth of the cache.
+func (c *GeocodeCache) evictLocked() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+
+	for key := range c.entries {
+		if len(c.entries) < maxCacheEntries*9/10 {
+			break
+		}
+		delete(c.entries, key)
+	}
+}
+
+func copyLocation(location *Location) *Location {
+	if location == nil {
+		return nil
+	}
+	copied := *location
+	return &copied
+}
@@ -0,0 +1,88 @@
+package geo
+
+import (
+	"math"
+)
+
+// Point is a latitude/longitude pair.
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// PathLength returns the length of the polyline in meters.
+func PathLength(path []Point) float64 {
+	var length float64
+	for i := 1; i < len(path); i++ {
+		length += CalculateDistance(path[i-1].Lat, path[i-1].Lng, path[i].Lat, path[i].Lng)
+	}
+	return length
+}
+
+// DistanceToPath returns how far the point is from the polyline and how far
+// along the polyline, measured from its start, the closest position lies.
+// Both are in meters.
+func DistanceToPath(lat, lng float64, path []Point) (distance, along float64) {
+	if len(path) == 0 {
+		return math.Inf(1), 0
+	}
+	if len(path) == 1 {
+		return CalculateDistance(lat, lng, path[0].Lat, path[0].Lng), 0
+	}
+
+	distance = math.Inf(1)
+	var walked float64
+	for i := 1; i < len(path); i++ {
+		a, b := path[i-1], path[i]
+		d, t := distanceToSegment(lat, lng, a, b)
+		segment := CalculateDistance(a.Lat, a.Lng, b.Lat, b.Lng)
+		if d < distance {
+			distance = d
+			along = walked + t*segment
+		}
+		walked += segment
+	}
+
+	return distance, along
+}
+
+// distanceToSegment projects the point onto segment ab in a local flat
+// approximation and returns the distance to the projection and its position t
+// on the segment, from 0 at a to 1 at b.
+func distanceToSegment(lat, lng float64, a, b Point) (float64, float64) {
+	scale := math.Cos(a.Lat * math.Pi / 180)
+
+	bx, by := (b.Lng-a.Lng)*scale, b.Lat-a.Lat
+	px, py := (lng-a.Lng)*scale, lat-a.Lat
+
+	var t float64
+	if lengthSq := bx*bx + by*by; lengthSq > 0 {
+		t = math.Max(0, math.Min(1, (px*bx+py*by)/lengthSq))
+	}
+
+	projLat := a.Lat + t*(b.Lat-a.Lat)
+	projLng := a.Lng + t*(b.Lng-a.Lng)
+	return CalculateDistance(lat, lng, projLat, projLng), t
+}
+
+// SamplePath returns points along the polyline no more than step meters apart,
+// including every vertex.
+func SamplePath(path []Point, step float64) []Point {
+	if len(path) < 2 || step <= 0 {
+		return path
+	}
+
+	samples := []Point{path[0]}
+	for i := 1; i < len(path); i++ {
+		a, b := path[i-1], path[i]
+		n := int(math.Ceil(CalculateDistance(a.Lat, a.Lng, b.Lat, b.Lng) / step))
+		for j := 1; j <= n; j++ {
+			t := float64(j) / float64(n)
+			samples = append(samples, Point{
+				Lat: a.Lat + t*(b.Lat-a.Lat),
+				Lng: a.Lng + t*(b.Lng-a.Lng),
+			})
+		}
+	}
+	return samples
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"time"
 
 	"googlemaps.github.io/maps"
 )
 
 type GeocodeClient struct {
 	client *maps.Client
+	// cache is optional; lookups go straight to the API without it
+	cache  *GeocodeCache
 }
 
 type Location struct {
@@ -26,7 +29,29 @@ func NewGeocodeClient(apiKey string) (*GeocodeClient, error) {
 	return &GeocodeClient{client: client}, nil
 }
 
+// SetCache caches lookups, keyed by normalized address and rounded coordinates.
+func (gc *GeocodeClient) SetCache(cache *GeocodeCache) {
+	gc.cache = cache
+}
+
+// CacheStats returns the cache counters, or zero stats when there is no cache.
+func (gc *GeocodeClient) CacheStats() CacheStats {
+	if gc == nil || gc.cache == nil {
+		return CacheStats{}
+	}
+	return gc.cache.Stats()
+}
+
 func (gc *GeocodeClient) GeocodeAddress(ctx context.Context, address string) (*Location, error) {
+	if gc.cache == nil {
+		return gc.geocodeAddress(ctx, address)
+	}
+	return gc.cache.Do(ctx, AddressKey(address), gc.cache.config.TTL, func(ctx context.Context) (*Location, error) {
+		return gc.geocodeAddress(ctx, address)
+	})
+}
+
+func (gc *GeocodeClient) geocodeAddress(ctx context.Context, address string) (*Location, error) {
 	req := &maps.GeocodingRequest{
 		Address: address,
 	}
@@ -49,6 +74,22 @@ func (gc *GeocodeClient) GeocodeAddress(ctx context.Context, address string) (*L
 }
 
 func (gc *GeocodeClient) ReverseGeocode(ctx context.Context, lat, lng float64) (*Location, error) {
+	if gc.cache == nil {
+		return gc.reverseGeocode(ctx, lat, lng)
+	}
+
+	location, err := gc.cache.Do(ctx, CoordinateKey(lat, lng), gc.cache.config.ReverseTTL, func(ctx context.Context) (*Location, error) {
+		return gc.reverseGeocode(ctx, lat, lng)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 快取的是附近座標的結果，座標仍回傳使用者實際的位置
+	location.Lat, location.Lng = lat, lng
+	return location, nil
+}
+
+func (gc *GeocodeClient) reverseGeocode(ctx context.Context, lat, lng float64) (*Location, error) {
 	req := &maps.GeocodingRequest{
 		LatLng: &maps.LatLng{
 			Lat: lat,
@@ -73,10 +114,6 @@ func (gc *GeocodeClient) ReverseGeocode(ctx context.Context, lat, lng float64) (
 	}, nil
 }
 
-func (gc *GeocodeClient) GetDirectionsURL(lat, lng float64) string {
-	return fmt.Sprintf("https://maps.google.com/?q=%f,%f", lat, lng)
-}
-
 func CalculateDistance(lat1, lng1, lat2, lng2 float64) float64 {
 	const earthRadius = 6371000
 
@@ -93,6 +130,14 @@ func CalculateDistance(lat1, lng1, lat2, lng2 float64) float64 {
 	return earthRadius * c
 }
 
+// WalkingSpeed is the assumed walking pace in meters per minute (about 4.8 km/h).
+const WalkingSpeed = 80.0
+
+// WalkingTime estimates how long it takes to walk the given distance.
+func WalkingTime(meters float64) time.Duration {
+	return time.Duration(meters / WalkingSpeed * float64(time.Minute))
+}
+
 func FormatDistance(meters float64) string {
 	if meters < 1000 {
 		return fmt.Sprintf("約%.0f公尺", meters)
@@ -0,0 +1,59 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Geocoder converts between addresses and coordinates.
+type Geocoder interface {
+	GeocodeAddress(ctx context.Context, address string) (*Location, error)
+	ReverseGeocode(ctx context.Context, lat, lng float64) (*Location, error)
+}
+
+// FallbackGeocoder tries the primary geocoder and, when it fails, e.g. because
+// the Maps quota has run out, the fallback.
+type FallbackGeocoder struct {
+	primary  Geocoder
+	fallback Geocoder
+}
+
+func NewFallbackGeocoder(primary, fallback Geocoder) *FallbackGeocoder {
+	return &FallbackGeocoder{primary: primary, fallback: fallback}
+}
+
+func (fg *FallbackGeocoder) GeocodeAddress(ctx context.Context, address string) (*Location, error) {
+	location, err := fg.primary.GeocodeAddress(ctx, address)
+	if err == nil || !shouldFallback(ctx, err) {
+		return location, err
+	}
+
+	log.Printf("Primary geocoder failed for '%s', using fallback: %v", address, err)
+	location, fallbackErr := fg.fallback.GeocodeAddress(ctx, address)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%w (fallback: %v)", err, fallbackErr)
+	}
+	return location, nil
+}
+
+func (fg *FallbackGeocoder) ReverseGeocode(ctx context.Context, lat, lng float64) (*Location, error) {
+	location, err := fg.primary.ReverseGeocode(ctx, lat, lng)
+	if err == nil || !shouldFallback(ctx, err) {
+		return location, err
+	}
+
+	log.Printf("Primary reverse geocoder failed for %f,%f, using fallback: %v", lat, lng, err)
+	location, fallbackErr := fg.fallback.ReverseGeocode(ctx, lat, lng)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%w (fallback: %v)", err, fallbackErr)
+	}
+	return location, nil
+}
+
+// shouldFallback is false when the request itself was cancelled, since the
+// fallback would be cancelled too.
+func shouldFallback(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,81 @@
+package geo
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MapProvider is the map app navigation links open in.
+type MapProvider string
+
+const (
+	MapProviderGoogle MapProvider = "google"
+	MapProviderApple  MapProvider = "apple"
+	MapProviderOSM    MapProvider = "osm"
+)
+
+// MapProviders lists the supported providers, the default first.
+var MapProviders = []MapProvider{MapProviderGoogle, MapProviderApple, MapProviderOSM}
+
+// ParseMapProvider accepts a provider ID or a common alias such as "gmap" or
+// "openstreetmap", returning false when it is none of them.
+func ParseMapProvider(s string) (MapProvider, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "google", "gmap", "googlemaps", "google maps":
+		return MapProviderGoogle, true
+	case "apple", "apple maps", "ios":
+		return MapProviderApple, true
+	case "osm", "openstreetmap":
+		return MapProviderOSM, true
+	}
+	return "", false
+}
+
+// Name is the provider's display name.
+func (p MapProvider) Name() string {
+	switch p {
+	case MapProviderApple:
+		return "Apple 地圖"
+	case MapProviderOSM:
+		return "OpenStreetMap"
+	default:
+		return "Google 地圖"
+	}
+}
+
+// WalkingDirectionsURL links to walking directions to the destination in the
+// provider's map. Without an origin the map app starts from the device's
+// current location. Unknown providers fall back to Google Maps.
+func WalkingDirectionsURL(provider MapProvider, origin *Point, lat, lng float64) string {
+	destination := formatPoint(lat, lng)
+
+	switch provider {
+	case MapProviderApple:
+		params := url.Values{"daddr": {destination}, "dirflg": {"w"}}
+		if origin != nil {
+			params.Set("saddr", formatPoint(origin.Lat, origin.Lng))
+		}
+		return "https://maps.apple.com/?" + params.Encode()
+
+	case MapProviderOSM:
+		// OSM 的 route 參數為「起點;終點」，起點留空時由使用者在網頁上定位
+		from := ""
+		if origin != nil {
+			from = formatPoint(origin.Lat, origin.Lng)
+		}
+		params := url.Values{"engine": {"fossgis_osrm_foot"}, "route": {from + ";" + destination}}
+		return "https://www.openstreetmap.org/directions?" + params.Encode()
+
+	default:
+		params := url.Values{"api": {"1"}, "destination": {destination}, "travelmode": {"walking"}}
+		if origin != nil {
+			params.Set("origin", formatPoint(origin.Lat, origin.Lng))
+		}
+		return "https://www.google.com/maps/dir/?" + params.Encode()
+	}
+}
+
+func formatPoint(lat, lng float64) string {
+	return fmt.Sprintf("%.6f,%.6f", lat, lng)
+}
@@ -0,0 +1,123 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Place is a named point the offline geocoder can resolve, such as a district
+// or 里 centroid or an entry of the local address table.
+type Place struct {
+	Name string  `json:"address"`
+	Lat  float64 `json:"lat"`
+	Lng  float64 `json:"lng"`
+}
+
+// OfflineGeocoder resolves addresses without network access, from a local
+// address table and the area centroids of the collection dataset. Results are
+// coarse, so it suits a degraded mode when the Maps API is unavailable and
+// deterministic tests.
+type OfflineGeocoder struct {
+	mu sync.RWMutex
+	// table holds the address table entries, areas the dataset centroids
+	table []Place
+	areas []Place
+}
+
+func NewOfflineGeocoder(table []Place) *OfflineGeocoder {
+	return &OfflineGeocoder{table: table}
+}
+
+// LoadAddressTable reads a JSON array of {"address", "lat", "lng"} entries.
+func LoadAddressTable(path string) ([]Place, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var table []Place
+	if err := json.Unmarshal(b, &table); err != nil {
+		return nil, fmt.Errorf("invalid address table %s: %w", path, err)
+	}
+	for _, place := range table {
+		if place.Name == "" {
+			return nil, fmt.Errorf("invalid address table %s: entry without address", path)
+		}
+	}
+
+	log.Printf("Loaded %d offline addresses from %s", len(table), path)
+	return table, nil
+}
+
+// SetAreas replaces the area centroids, e.g. after the dataset is refreshed.
+func (og *OfflineGeocoder) SetAreas(areas []Place) {
+	og.mu.Lock()
+	defer og.mu.Unlock()
+	og.areas = areas
+}
+
+// GeocodeAddress returns the address table entry or area whose name is the
+// longest one contained in the address, so "台北市大安區新生南路" resolves to the
+// 大安區 centroid when the street itself is not in the table.
+func (og *OfflineGeocoder) GeocodeAddress(ctx context.Context, address string) (*Location, error) {
+	query := offlineKey(address)
+	if query == "" {
+		return nil, fmt.Errorf("no results found for address: %s", address)
+	}
+
+	og.mu.RLock()
+	defer og.mu.RUnlock()
+
+	var best *Place
+	bestLength := 0
+	for _, places := range [][]Place{og.table, og.areas} {
+		for i := range places {
+			name := offlineKey(places[i].Name)
+			if name == "" || !strings.Contains(query, name) {
+				continue
+			}
+			// 名稱越長越精確；長度相同時，地址表優先於行政區中心
+			if length := utf8.RuneCountInString(name); length > bestLength {
+				best, bestLength = &places[i], length
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no results found for address: %s", address)
+	}
+	return &Location{Lat: best.Lat, Lng: best.Lng, Address: best.Name}, nil
+}
+
+// ReverseGeocode names the coordinates after the closest known place.
+func (og *OfflineGeocoder) ReverseGeocode(ctx context.Context, lat, lng float64) (*Location, error) {
+	og.mu.RLock()
+	defer og.mu.RUnlock()
+
+	var best *Place
+	bestDistance := math.Inf(1)
+	for _, places := range [][]Place{og.table, og.areas} {
+		for i := range places {
+			if distance := CalculateDistance(lat, lng, places[i].Lat, places[i].Lng); distance < bestDistance {
+				best, bestDistance = &places[i], distance
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no results found for coordinates: %f, %f", lat, lng)
+	}
+	return &Location{Lat: lat, Lng: lng, Address: best.Name}, nil
+}
+
+// offlineKey normalizes names the same way as cache keys, without spaces.
+func offlineKey(name string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(AddressKey(name), "addr:"), " ", "")
+}
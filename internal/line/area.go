@@ -0,0 +1,66 @@
+package line
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/gemini"
+)
+
+// searchArea answers queries naming only a district or neighbourhood from the
+// dataset gazetteer. It returns false when the text is not such a query, so the
+// caller can fall back to geocoding.
+func (h *Handler) searchArea(ctx context.Context, userID, text string, intent *gemini.IntentResult) bool {
+	garbageData, err := h.garbageAdapter.GetGarbageData(ctx)
+	if err != nil {
+		log.Printf("Error fetching garbage data for area lookup: %v", err)
+		return false
+	}
+
+	areas := garbageData.Gazetteer().Lookup(text)
+	if len(areas) == 0 {
+		return false
+	}
+
+	log.Printf("Gazetteer resolved '%s' to %d areas for user %s", text, len(areas), userID)
+
+	if len(areas) > 1 {
+		h.sendAreaChoices(ctx, userID, text, areas, intent)
+		return true
+	}
+
+	h.runStopQuery(ctx, userID, h.areaQuery(areas[0], intent))
+	return true
+}
+
+// areaQuery lists the stops serving the area, soonest first.
+func (h *Handler) areaQuery(area *garbage.Area, intent *gemini.IntentResult) stopQuery {
+	query := stopQuery{Lat: area.Lat, Lng: area.Lng, Area: area.ID, Sort: garbage.SortSoonest}
+	h.applyIntent(&query, intent)
+	return query
+}
+
+// sendAreaChoices asks which area was meant when a name matches several, e.g.
+// 中正區 in both Taipei and Keelung.
+func (h *Handler) sendAreaChoices(ctx context.Context, userID, text string, areas []*garbage.Area, intent *gemini.IntentResult) {
+	var items []messaging_api.QuickReplyItem
+	for _, area := range areas {
+		items = append(items, messaging_api.QuickReplyItem{
+			Action: &messaging_api.PostbackAction{
+				Label:       area.Name(),
+				Data:        h.areaQuery(area, intent).postbackData(),
+				DisplayText: area.Name(),
+			},
+		})
+	}
+
+	message := &messaging_api.TextMessage{
+		Text:       fmt.Sprintf("「%s」符合 %d 個地區，請選擇您要查詢的地區：", text, len(areas)),
+		QuickReply: &messaging_api.QuickReply{Items: items},
+	}
+	h.sendMessage(ctx, userID, message)
+}
@@ -0,0 +1,121 @@
+package line
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/geo"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// maxChaseStops caps the stops listed in the chase bubble.
+const maxChaseStops = 5
+
+func (h *Handler) handleChasePostback(ctx context.Context, userID string, params map[string]string) {
+	lat, latErr := strconv.ParseFloat(params["lat"], 64)
+	lng, lngErr := strconv.ParseFloat(params["lng"], 64)
+	if latErr != nil || lngErr != nil {
+		h.replyMessage(ctx, userID, "抱歉，位置資訊錯誤，請重新查詢。")
+		return
+	}
+
+	garbageData, err := h.garbageAdapter.GetGarbageData(ctx)
+	if err != nil {
+		log.Printf("Error fetching garbage data for chase: %v", err)
+		h.replyMessage(ctx, userID, "抱歉，無法取得垃圾車資料。")
+		return
+	}
+
+	routeID, stopName := params["route"], params["stop"]
+	stops, err := h.garbageAdapter.ChaseTruck(garbageData, routeID, stopName, lat, lng, utils.NowInTaiwan())
+	if err != nil {
+		log.Printf("Error chasing route %s from stop %s: %v", routeID, stopName, err)
+		h.replyMessage(ctx, userID, "抱歉，找不到這條路線的資料。")
+		return
+	}
+
+	log.Printf("Found %d catchable downstream stops on route %s for user %s", len(stops), routeID, userID)
+
+	if len(stops) == 0 {
+		h.replyMessage(ctx, userID, "😢 垃圾車後面的站點都來不及走到了，請改搭下一班。")
+		return
+	}
+
+	route := h.garbageAdapter.GetRouteByID(garbageData, routeID)
+	bubble := h.createChaseBubble(route, stops, h.mapProvider(ctx, userID), &geo.Point{Lat: lat, Lng: lng})
+	flexMessage := messaging_api.FlexMessage{
+		AltText:  "追垃圾車：還來得及的站點",
+		Contents: &bubble,
+	}
+
+	h.sendMessage(ctx, userID, &flexMessage)
+}
+
+func (h *Handler) createChaseBubble(route *garbage.Route, stops []*garbage.ChaseStop, provider geo.MapProvider, origin *geo.Point) messaging_api.FlexBubble {
+	contents := []messaging_api.FlexComponentInterface{
+		&messaging_api.FlexText{
+			Text:   "🏃 追垃圾車",
+			Weight: "bold",
+			Size:   "lg",
+		},
+		&messaging_api.FlexText{
+			Text:  fmt.Sprintf("%s・車號 %s，以下站點現在出發還來得及（點選可導航）", route.Name, route.VehicleNumber),
+			Size:  "xs",
+			Color: "#888888",
+			Wrap:  true,
+		},
+		&messaging_api.FlexSeparator{Margin: "md"},
+	}
+
+	for i, stop := range stops {
+		if i >= maxChaseStops {
+			break
+		}
+
+		spare := "垃圾車會先到，請盡快出發"
+		if stop.Spare > 0 {
+			spare = fmt.Sprintf("約提早 %s到", formatCountdown(stop.Spare))
+		}
+
+		contents = append(contents, &messaging_api.FlexBox{
+			Layout: "vertical",
+			Margin: "md",
+			Action: &messaging_api.UriAction{
+				Label: "導航",
+				Uri:   geo.WalkingDirectionsURL(provider, origin, stop.Stop.Lat, stop.Stop.Lng),
+			},
+			Contents: []messaging_api.FlexComponentInterface{
+				&messaging_api.FlexText{
+					Text:   fmt.Sprintf("%d. %s", i+1, stop.Stop.Name),
+					Weight: "bold",
+					Size:   "sm",
+					Wrap:   true,
+				},
+				&messaging_api.FlexText{
+					Text:  fmt.Sprintf("🚛 %s－%s 停靠", stop.Arrival.Format("15:04"), stop.Departure.Format("15:04")),
+					Size:  "sm",
+					Color: "#1DB446",
+				},
+				&messaging_api.FlexText{
+					Text:  fmt.Sprintf("🚶 %s・步行約 %d 分鐘・%s", geo.FormatDistance(stop.Distance), int(math.Ceil(stop.WalkTime.Minutes())), spare),
+					Size:  "xs",
+					Color: "#666666",
+					Wrap:  true,
+				},
+			},
+		})
+	}
+
+	return messaging_api.FlexBubble{
+		Body: &messaging_api.FlexBox{
+			Layout:   "vertical",
+			Contents: contents,
+		},
+	}
+}
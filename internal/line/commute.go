@@ -0,0 +1,241 @@
+package line
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/geo"
+	"linebot-garbage-helper/internal/store"
+	"linebot-garbage-helper/internal/utils"
+)
+
+const (
+	// defaultCommuteCorridor is the corridor half-width when none is configured.
+	defaultCommuteCorridor = 300.0
+	// lastLocationTTL is how long a shared location can be used as a commute start.
+	lastLocationTTL = 2 * time.Hour
+	// maxCommuteResults caps the bubbles in one commute reply.
+	maxCommuteResults = 5
+)
+
+const commuteUsage = `請使用：/commute [出發地] [目的地] [出發時間]
+
+例如：
+/commute 公司 家 18:30 - 從收藏的「公司」到「家」
+/commute 家 - 從最近分享的位置到「家」
+
+出發地與目的地為收藏地點名稱，出發時間可省略（預設現在）。`
+
+// SetCommuteCorridor sets how far from the commute path stops are searched, in meters.
+func (h *Handler) SetCommuteCorridor(meters float64) {
+	h.commuteCorridor = meters
+}
+
+func (h *Handler) handleCommuteCommand(ctx context.Context, userID string, args []string) {
+	now := utils.NowInTaiwan()
+	depart := now
+
+	var names []string
+	for _, arg := range args {
+		if arg == "" {
+			continue
+		}
+		if t, err := time.ParseInLocation("15:04", arg, now.Location()); err == nil {
+			depart = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+			// 指定的時間已經過了，視為明天
+			if depart.Before(now.Add(-time.Minute)) {
+				depart = depart.AddDate(0, 0, 1)
+			}
+			continue
+		}
+		names = append(names, arg)
+	}
+
+	if len(names) == 0 || len(names) > 2 {
+		h.replyMessage(ctx, userID, commuteUsage)
+		return
+	}
+
+	user, err := h.store.GetUser(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting user %s for commute: %v", userID, err)
+		h.replyMessage(ctx, userID, "找不到您的收藏地點，請先使用 /favorite 收藏出發地與目的地。")
+		return
+	}
+
+	var from, to *store.Favorite
+	if len(names) == 2 {
+		from = findFavorite(user, names[0])
+		to = findFavorite(user, names[1])
+	} else {
+		to = findFavorite(user, names[0])
+		if user.LastLocation == nil || now.Sub(user.LastLocationAt) > lastLocationTTL {
+			h.replyMessage(ctx, userID, "請先分享您目前的位置，或同時指定出發地與目的地。\n\n"+commuteUsage)
+			return
+		}
+		from = user.LastLocation
+		from.Name = "目前位置"
+	}
+
+	if from == nil {
+		h.replyMessage(ctx, userID, fmt.Sprintf("找不到收藏地點「%s」，請使用 /list 查看收藏清單。", names[0]))
+		return
+	}
+	if to == nil {
+		h.replyMessage(ctx, userID, fmt.Sprintf("找不到收藏地點「%s」，請使用 /list 查看收藏清單。", names[len(names)-1]))
+		return
+	}
+
+	h.searchCommute(ctx, userID, from, to, depart)
+}
+
+func (h *Handler) searchCommute(ctx context.Context, userID string, from, to *store.Favorite, depart time.Time) {
+	garbageData, err := h.garbageAdapter.GetGarbageData(ctx)
+	if err != nil {
+		log.Printf("Error fetching garbage data for commute: %v", err)
+		h.replyMessage(ctx, userID, "抱歉，無法取得垃圾車資料。")
+		return
+	}
+
+	corridor := h.commuteCorridor
+	if corridor <= 0 {
+		corridor = defaultCommuteCorridor
+	}
+
+	path := []geo.Point{{Lat: from.Lat, Lng: from.Lng}, {Lat: to.Lat, Lng: to.Lng}}
+	stops, err := h.garbageAdapter.FindStopsAlongPath(garbageData, garbage.CorridorQuery{
+		Path:   path,
+		Width:  corridor,
+		Depart: depart,
+	})
+	if err != nil {
+		log.Printf("Error searching commute corridor for user %s: %v", userID, err)
+		h.replyMessage(ctx, userID, "抱歉，無法查詢沿途的垃圾車站點。")
+		return
+	}
+
+	log.Printf("Found %d stops along commute %s → %s for user %s", len(stops), from.Name, to.Name, userID)
+
+	length := geo.PathLength(path)
+	summary := fmt.Sprintf("🚶 %s → %s\n%s 出發，全程%s，步行約 %d 分鐘",
+		from.Name, to.Name, depart.Format("15:04"), geo.FormatDistance(length),
+		int(geo.WalkingTime(length).Minutes()+0.5))
+
+	if len(stops) == 0 {
+		h.replyMessage(ctx, userID, fmt.Sprintf("%s\n\n沿途 %.0f 公尺內，經過時沒有剛好到站的垃圾車。", summary, corridor))
+		return
+	}
+
+	h.replyMessage(ctx, userID, fmt.Sprintf("%s\n\n沿途有 %d 個站點經過時剛好有垃圾車：", summary, len(stops)))
+
+	provider := h.mapProvider(ctx, userID)
+	var bubbles []messaging_api.FlexBubble
+	for i, stop := range stops {
+		if i >= maxCommuteResults {
+			break
+		}
+		bubbles = append(bubbles, h.createCommuteBubble(stop, depart, provider))
+	}
+
+	flexMessage := messaging_api.FlexMessage{
+		AltText:  fmt.Sprintf("%s → %s 沿途垃圾車", from.Name, to.Name),
+		Contents: &messaging_api.FlexCarousel{Contents: bubbles},
+	}
+	h.sendMessage(ctx, userID, &flexMessage)
+}
+
+func (h *Handler) createCommuteBubble(stop *garbage.CorridorStop, depart time.Time, provider geo.MapProvider) messaging_api.FlexBubble {
+	truck := fmt.Sprintf("🚛 垃圾車 %s－%s", stop.ETA.Format("15:04"), stop.Departure.Format("15:04"))
+	if stop.Status == garbage.StopStatusAtStop {
+		truck = fmt.Sprintf("🟢 經過時垃圾車在站中，%s 離開", stop.Departure.Format("15:04"))
+	}
+
+	reminderData := fmt.Sprintf("route=%s&stop=%s&eta=%d", stop.Route.ID, stop.Stop.Name, stop.ETA.Unix())
+
+	return messaging_api.FlexBubble{
+		Body: &messaging_api.FlexBox{
+			Layout: "vertical",
+			Contents: []messaging_api.FlexComponentInterface{
+				&messaging_api.FlexText{
+					Text:   stop.Stop.Name,
+					Weight: "bold",
+					Size:   "lg",
+					Wrap:   true,
+				},
+				&messaging_api.FlexText{
+					Text: fmt.Sprintf("🚶 %s 經過（出發後 %d 分鐘）", stop.PassAt.Format("15:04"), int(stop.PassAt.Sub(depart).Minutes())),
+					Size: "md",
+				},
+				&messaging_api.FlexText{
+					Text:  truck,
+					Size:  "sm",
+					Color: "#1DB446",
+					Wrap:  true,
+				},
+				&messaging_api.FlexText{
+					Text:  fmt.Sprintf("離路線%s・路線：%s", geo.FormatDistance(stop.Distance), stop.Route.Name),
+					Size:  "sm",
+					Color: "#888888",
+					Wrap:  true,
+				},
+			},
+		},
+		Footer: &messaging_api.FlexBox{
+			Layout: "horizontal",
+			Contents: []messaging_api.FlexComponentInterface{
+				&messaging_api.FlexButton{
+					Action: &messaging_api.UriAction{
+						Label: "導航",
+						// 沿途的站點由當下所在位置出發
+						Uri: geo.WalkingDirectionsURL(provider, nil, stop.Stop.Lat, stop.Stop.Lng),
+					},
+					Style: "secondary",
+				},
+				&messaging_api.FlexButton{
+					Action: &messaging_api.PostbackAction{
+						Label: "提醒我",
+						Data:  reminderData,
+					},
+					Style: "primary",
+				},
+			},
+		},
+	}
+}
+
+// findFavorite matches a favorite by name. An exact match wins; otherwise a
+// partial match is used only when exactly one favorite matches, so "家" does not
+// pick "老家" when both exist. Empty names never match.
+func findFavorite(user *store.User, name string) *store.Favorite {
+	lowerName := strings.ToLower(strings.TrimSpace(name))
+	if lowerName == "" {
+		return nil
+	}
+
+	var partial *store.Favorite
+	matches := 0
+	for i := range user.Favorites {
+		lowerFavName := strings.ToLower(strings.TrimSpace(user.Favorites[i].Name))
+		if lowerFavName == "" {
+			continue
+		}
+		if lowerFavName == lowerName {
+			return &user.Favorites[i]
+		}
+		if strings.Contains(lowerFavName, lowerName) || strings.Contains(lowerName, lowerFavName) {
+			partial = &user.Favorites[i]
+			matches++
+		}
+	}
+
+	if matches == 1 {
+		return partial
+	}
+	return nil
+}
@@ -6,7 +6,6 @@ import (
 	"log"
 	"math"
 	"net/http"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -18,23 +17,67 @@ import (
 	"linebot-garbage-helper/internal/gemini"
 	"linebot-garbage-helper/internal/geo"
 	"linebot-garbage-helper/internal/store"
+	"linebot-garbage-helper/internal/utils"
+)
+
+const (
+	welcomeMessage = `👋 您好！歡迎使用垃圾車助手！
+
+🚀 快速開始：
+📍 點擊下方「+」按鈕 → 選擇「位置」→「即時位置」
+💬 或直接輸入地址，例如：「台北市信義區」
+
+我會幫您找到最近的垃圾車站點和時間！
+
+輸入 /help 查看更多功能`
+
+	helpMessage = `歡迎使用垃圾車助手！
+
+🚛 查詢垃圾車：
+📍 分享位置：點擊「+」→「位置」→「即時位置」
+💬 輸入地址：「台北市大安區忠孝東路」
+🕐 時間查詢：「我晚上七點前在哪裡倒垃圾？」
+
+⭐ 收藏管理：
+/list - 查看收藏清單（含互動按鈕）
+/favorite 家 台北市大安區 - 新增收藏
+/delete 家 - 刪除收藏
+💬 也可以直接說「幫我把這裡存成公司」、「我的收藏」
+
+🚶 順路倒垃圾：
+/commute 公司 家 18:30 - 查詢下班路上經過時剛好有垃圾車的站點
+
+⏰ 提醒功能：
+點擊查詢結果中的「提醒我」按鈕設定通知
+💬 也可以說「全家復興店來之前提醒我」
+💬 說「取消明天的提醒」即可取消
+
+🗺 導航：
+/map - 選擇導航使用的地圖（Google、Apple、OpenStreetMap）
+點擊「📤 分享位置」可將站點位置轉傳給家人
+
+💡 更快速的收藏方式：
+🔸 分享位置後點擊「⭐ 收藏」
+🔸 查詢結果中點擊「收藏此地點」`
 )
 
 type Handler struct {
 	messagingAPI    *messaging_api.MessagingApiAPI
 	store           *store.FirestoreClient
-	geoClient       *geo.GeocodeClient
+	geoClient       geo.Geocoder
 	garbageAdapter  *garbage.GarbageAdapter
-	geminiClient    *gemini.GeminiClient
+	analyzer        gemini.Analyzer
 	channelSecret   string
+	searchRadius    float64
+	commuteCorridor float64
 }
 
 func NewHandler(
 	channelToken, channelSecret string,
 	store *store.FirestoreClient,
-	geoClient *geo.GeocodeClient,
+	geoClient geo.Geocoder,
 	garbageAdapter *garbage.GarbageAdapter,
-	geminiClient *gemini.GeminiClient,
+	analyzer gemini.Analyzer,
 ) (*Handler, error) {
 	messagingAPI, err := messaging_api.NewMessagingApiAPI(channelToken)
 	if err != nil {
@@ -46,7 +89,7 @@ func NewHandler(
 		store:          store,
 		geoClient:      geoClient,
 		garbageAdapter: garbageAdapter,
-		geminiClient:   geminiClient,
+		analyzer:       analyzer,
 		channelSecret:  channelSecret,
 	}, nil
 }
@@ -144,25 +187,16 @@ func (h *Handler) handleTextMessage(ctx context.Context, userID, text string) {
 		return
 	}
 
-	// Handle common greetings
-	lowerText := strings.ToLower(strings.TrimSpace(text))
-	if lowerText == "hi" || lowerText == "hello" || lowerText == "你好" || lowerText == "哈囉" {
-		log.Printf("Greeting detected: %s", text)
-		welcomeMsg := `👋 您好！歡迎使用垃圾車助手！
-
-🚀 快速開始：
-📍 點擊下方「+」按鈕 → 選擇「位置」→「即時位置」
-💬 或直接輸入地址，例如：「台北市信義區」
-
-我會幫您找到最近的垃圾車站點和時間！
+	// 收藏地點名稱優先於行政區查詢，例如收藏名稱就叫「信義區」或「大安」
+	favorite := h.findUserFavoriteByName(ctx, userID, text)
 
-輸入 /help 查看更多功能`
-		h.replyMessage(ctx, userID, welcomeMsg)
+	// 行政區或里名直接用資料集查詢，不需要意圖分析與地理編碼
+	if favorite == nil && h.searchArea(ctx, userID, text, nil) {
 		return
 	}
 
 	log.Printf("Analyzing intent for text: %s", text)
-	intent, err := h.geminiClient.AnalyzeIntent(ctx, text)
+	intent, err := h.analyzer.AnalyzeIntent(ctx, text)
 	if err != nil {
 		log.Printf("Error analyzing intent for user %s: %v", userID, err)
 		// 意圖分析失敗時，仍然嘗試作為地址處理
@@ -171,8 +205,12 @@ func (h *Handler) handleTextMessage(ctx context.Context, userID, text string) {
 	
 	log.Printf("Intent analysis result: %+v", intent)
 
+	// 收藏、提醒、說明等不是查詢垃圾車的意圖，直接交給對應的功能
+	if intent != nil && h.dispatchIntent(ctx, userID, intent) {
+		return
+	}
+
 	// 首先檢查是否是收藏地點名稱
-	favorite := h.findUserFavoriteByName(ctx, userID, text)
 	if favorite != nil {
 		log.Printf("Found favorite location '%s' for user %s: lat=%f, lng=%f", text, userID, favorite.Lat, favorite.Lng)
 		h.searchNearbyGarbageTrucks(ctx, userID, favorite.Lat, favorite.Lng, intent)
@@ -186,14 +224,29 @@ func (h *Handler) handleTextMessage(ctx context.Context, userID, text string) {
 		return
 	}
 
+	// 意圖中的地區是行政區或里名時，同樣不需要地理編碼
+	if intent != nil && intent.District != "" && h.searchArea(ctx, userID, intent.District, intent) {
+		return
+	}
+
+	// 站點名稱或地標（如「全家 復興店」）通常幾乎原樣存在資料中，比地理編碼準確
+	if h.searchLocationName(ctx, userID, text, intent) {
+		return
+	}
+
 	// 嘗試多種方式提取地址
 	var addressToGeocode string
 	var addressMethod string
 
-	// 方法1：優先使用原始文字作為地址（最準確）
+	// 方法1：優先使用原始文字作為地址（最準確）；能解析出路名時，改用正規化後的地址
+	// （全形數字、段的寫法一致，並去掉樓層等不影響位置的部分）
 	addressToGeocode = text
 	addressMethod = "original.text"
-	log.Printf("Method 1 - Using original text as address: %s", addressToGeocode)
+	if parsed := geo.ParseAddress(text); parsed.Road != "" {
+		addressToGeocode = parsed.String()
+		addressMethod = "parsed.address"
+	}
+	log.Printf("Method 1 - Using %s as address: %s", addressMethod, addressToGeocode)
 	
 	// 進行地理編碼
 	log.Printf("Geocoding address: '%s' using method: %s", addressToGeocode, addressMethod)
@@ -201,8 +254,8 @@ func (h *Handler) handleTextMessage(ctx context.Context, userID, text string) {
 	if err != nil {
 		log.Printf("Error geocoding address '%s' (method: %s) for user %s: %v", addressToGeocode, addressMethod, userID, err)
 
-		// Fallback 1: 嘗試使用 Gemini ExtractLocationFromText
-		extractedLocation, extractErr := h.geminiClient.ExtractLocationFromText(ctx, text)
+		// Fallback 1: 從文字中抽取地點（Gemini 或規則式分析）
+		extractedLocation, extractErr := h.analyzer.ExtractLocationFromText(ctx, text)
 		if extractErr == nil && extractedLocation != "" && strings.TrimSpace(extractedLocation) != text {
 			log.Printf("Fallback 1: trying extracted location: %s", extractedLocation)
 			location, err = h.geoClient.GeocodeAddress(ctx, extractedLocation)
@@ -248,21 +301,14 @@ func (h *Handler) handleTextMessage(ctx context.Context, userID, text string) {
 }
 
 func (h *Handler) handleTimeQueryWithoutLocation(ctx context.Context, userID string, intent *gemini.IntentResult) {
-	fromTime, toTime, err := h.geminiClient.ParseTimeWindow(intent.TimeWindow)
+	fromTime, toTime, err := h.analyzer.ParseTimeWindow(intent.TimeWindow)
 	if err != nil {
 		log.Printf("Error parsing time window: %v", err)
 		h.replyMessage(ctx, userID, "抱歉，無法理解您指定的時間。")
 		return
 	}
 
-	var timeDesc string
-	if !toTime.IsZero() {
-		timeDesc = fmt.Sprintf("%s前", toTime.Format("15:04"))
-	} else if !fromTime.IsZero() {
-		timeDesc = fmt.Sprintf("%s後", fromTime.Format("15:04"))
-	} else {
-		timeDesc = "指定時間內"
-	}
+	timeDesc := describeTimeWindow(fromTime, toTime)
 
 	// 檢查用戶是否有收藏地點
 	user, err := h.store.GetUser(ctx, userID)
@@ -314,6 +360,12 @@ func (h *Handler) handleLocationMessage(ctx context.Context, userID string, lat,
 		confirmMsg = "📍 收到您的位置\n\n正在為您查詢附近的垃圾車..."
 	}
 	h.replyMessage(ctx, userID, confirmMsg)
+
+	// 記住最近分享的位置，供 /commute 當作出發地
+	lastLocation := store.Favorite{Name: "目前位置", Lat: lat, Lng: lng, Address: address}
+	if err := h.store.UpdateLastLocation(ctx, userID, lastLocation); err != nil {
+		log.Printf("Error saving last location for user %s: %v", userID, err)
+	}
 	
 	// Search for nearby garbage trucks and offer to save location
 	h.searchNearbyGarbageTrucksWithSaveOption(ctx, userID, lat, lng, address, nil)
@@ -323,8 +375,8 @@ func (h *Handler) searchNearbyGarbageTrucksWithSaveOption(ctx context.Context, u
 	// 先搜尋垃圾車
 	h.searchNearbyGarbageTrucks(ctx, userID, lat, lng, intent)
 	
-	// 然後詢問是否要收藏此位置
-	if address != "" {
+	// 然後詢問是否要收藏此位置（不在服務範圍內的位置收藏了也查不到）
+	if address != "" && h.isCovered(ctx, lat, lng) {
 		h.offerLocationSave(ctx, userID, lat, lng, address)
 	}
 }
@@ -436,25 +488,7 @@ func (h *Handler) handleCommand(ctx context.Context, userID, command string) {
 
 	switch cmd {
 	case "/help":
-		helpText := `歡迎使用垃圾車助手！
-
-🚛 查詢垃圾車：
-📍 分享位置：點擊「+」→「位置」→「即時位置」
-💬 輸入地址：「台北市大安區忠孝東路」
-🕐 時間查詢：「我晚上七點前在哪裡倒垃圾？」
-
-⭐ 收藏管理：
-/list - 查看收藏清單（含互動按鈕）
-/favorite 家 台北市大安區 - 新增收藏
-/delete 家 - 刪除收藏
-
-⏰ 提醒功能：
-點擊查詢結果中的「提醒我」按鈕設定通知
-
-💡 更快速的收藏方式：
-🔸 分享位置後點擊「⭐ 收藏」
-🔸 查詢結果中點擊「收藏此地點」`
-		h.replyMessage(ctx, userID, helpText)
+		h.replyMessage(ctx, userID, helpMessage)
 
 	case "/favorite", "/add", "/save":
 		if len(parts) < 2 {
@@ -468,6 +502,12 @@ func (h *Handler) handleCommand(ctx context.Context, userID, command string) {
 	case "/list":
 		h.listFavoritesWithUI(ctx, userID)
 		
+	case "/commute":
+		h.handleCommuteCommand(ctx, userID, parts[1:])
+
+	case "/map":
+		h.handleMapCommand(ctx, userID, parts[1:])
+
 	case "/delete", "/remove":
 		if len(parts) < 2 {
 			h.replyMessage(ctx, userID, "請使用：/delete [地點名稱]")
@@ -483,53 +523,17 @@ func (h *Handler) handleCommand(ctx context.Context, userID, command string) {
 
 func (h *Handler) searchNearbyGarbageTrucks(ctx context.Context, userID string, lat, lng float64, intent *gemini.IntentResult) {
 	log.Printf("Searching nearby garbage trucks for user %s at coordinates: lat=%f, lng=%f", userID, lat, lng)
-	
-	garbageData, err := h.garbageAdapter.FetchGarbageData(ctx)
-	if err != nil {
-		log.Printf("Error fetching garbage data for user %s: %v", userID, err)
-		h.replyMessage(ctx, userID, "抱歉，無法取得垃圾車資料。")
-		return
-	}
-	
-	log.Printf("Successfully fetched garbage data, %d collection points available", len(garbageData.Result.Results))
-
-	var nearestStops []*garbage.NearestStop
-
-	if intent != nil && (intent.TimeWindow.From != "" || intent.TimeWindow.To != "") {
-		log.Printf("Time window query detected: from=%s, to=%s", intent.TimeWindow.From, intent.TimeWindow.To)
-		fromTime, toTime, err := h.geminiClient.ParseTimeWindow(intent.TimeWindow)
-		if err == nil {
-			log.Printf("Parsed time window: from=%v, to=%v", fromTime, toTime)
-			timeWindow := garbage.TimeWindow{From: fromTime, To: toTime}
-			nearestStops, err = h.garbageAdapter.FindStopsInTimeWindow(lat, lng, garbageData, timeWindow, 2000)
-			log.Printf("Found %d stops in time window", len(nearestStops))
-		} else {
-			log.Printf("Error parsing time window: %v", err)
-		}
-	}
 
-	if len(nearestStops) == 0 {
-		log.Printf("No stops found in time window, searching for nearest stops")
-		nearestStops, err = h.garbageAdapter.FindNearestStops(lat, lng, garbageData, 5)
-		if err != nil {
-			log.Printf("Error finding nearest stops for user %s: %v", userID, err)
-			h.replyMessage(ctx, userID, "抱歉，無法找到附近的垃圾車站點。")
-			return
-		}
-		log.Printf("Found %d nearest stops", len(nearestStops))
-	}
-
-	if len(nearestStops) == 0 {
-		log.Printf("No garbage truck stops found for user %s at coordinates lat=%f, lng=%f", userID, lat, lng)
-		h.replyMessage(ctx, userID, "附近沒有找到垃圾車站點。")
-		return
-	}
+	query := stopQuery{Lat: lat, Lng: lng, Sort: garbage.SortBest}
+	h.applyIntent(&query, intent)
 
-	log.Printf("Sending %d garbage truck results to user %s", len(nearestStops), userID)
-	h.sendGarbageTruckResults(ctx, userID, nearestStops)
+	h.runStopQuery(ctx, userID, query)
 }
 
-func (h *Handler) sendGarbageTruckResults(ctx context.Context, userID string, stops []*garbage.NearestStop) {
+// sendGarbageTruckResults sends the page of stops at the query's cursor. partial
+// means stops holds only the stops up to a little past this page, so how many
+// remain is not known.
+func (h *Handler) sendGarbageTruckResults(ctx context.Context, userID string, stops []*garbage.NearestStop, query stopQuery, partial bool) {
 	log.Printf("Preparing to send garbage truck results to user %s", userID)
 	
 	if len(stops) == 0 {
@@ -539,17 +543,23 @@ func (h *Handler) sendGarbageTruckResults(ctx context.Context, userID string, st
 
 	var bubbles []messaging_api.FlexBubble
 
-	for i, stop := range stops {
-		if i >= 3 {
-			log.Printf("Limiting results to first 3 stops")
-			break
-		}
-
-		log.Printf("Creating bubble for stop %d: %s", i+1, stop.Stop.Name)
-		bubble := h.createGarbageTruckBubble(stop)
+	provider := h.mapProvider(ctx, userID)
+	end := min(query.Cursor+resultsPageSize, len(stops))
+	for i := query.Cursor; i < end; i++ {
+		log.Printf("Creating bubble for stop %d: %s", i+1, stops[i].Stop.Name)
+		bubble := h.createGarbageTruckBubble(stops[i], query.Lat, query.Lng, provider, query.origin(), query.At)
 		bubbles = append(bubbles, bubble)
 	}
 
+	if remaining := len(stops) - end; remaining > 0 {
+		next := query
+		next.Cursor = end
+		if partial {
+			remaining = 0
+		}
+		bubbles = append(bubbles, h.moreResultsBubble(next, remaining))
+	}
+
 	log.Printf("Created %d bubbles for user %s", len(bubbles), userID)
 	
 	carousel := messaging_api.FlexCarousel{
@@ -557,18 +567,26 @@ func (h *Handler) sendGarbageTruckResults(ctx context.Context, userID string, st
 	}
 
 	flexMessage := messaging_api.FlexMessage{
-		AltText:  "垃圾車查詢結果",
-		Contents: &carousel,
+		AltText:    "垃圾車查詢結果",
+		Contents:   &carousel,
+		QuickReply: h.sortQuickReply(query),
 	}
 
 	log.Printf("Sending flex message with %d bubbles to user %s", len(bubbles), userID)
 	h.sendMessage(ctx, userID, &flexMessage)
 }
 
-func (h *Handler) createGarbageTruckBubble(stop *garbage.NearestStop) messaging_api.FlexBubble {
-	timeStr := stop.ETA.Format("15:04")
+// createGarbageTruckBubble renders one stop of the results. now is the time the
+// results were ranked at, so every page of a query agrees on what was missed.
+func (h *Handler) createGarbageTruckBubble(stop *garbage.NearestStop, userLat, userLng float64, provider geo.MapProvider, origin *geo.Point, now time.Time) messaging_api.FlexBubble {
+	timeStr := formatETA(stop.ETA)
+	etaLabel := "下一班"
+	if stop.Status == garbage.StopStatusAtStop {
+		etaLabel = "本班"
+	}
 	distanceStr := geo.FormatDistance(stop.Distance)
-	directionsURL := h.geoClient.GetDirectionsURL(stop.Stop.Lat, stop.Stop.Lng)
+	walkingMinutes := int(math.Ceil(geo.WalkingTime(stop.Distance).Minutes()))
+	directionsURL := geo.WalkingDirectionsURL(provider, origin, stop.Stop.Lat, stop.Stop.Lng)
 
 	reminderData := fmt.Sprintf("route=%s&stop=%s&eta=%d", 
 		stop.Route.ID, stop.Stop.Name, stop.ETA.Unix())
@@ -581,12 +599,13 @@ func (h *Handler) createGarbageTruckBubble(stop *garbage.NearestStop) messaging_
 				Weight: "bold",
 				Size:   "lg",
 			},
+			h.createStopStatusText(stop),
 			&messaging_api.FlexText{
-				Text: fmt.Sprintf("下一班：%s", timeStr),
+				Text: fmt.Sprintf("%s：%s", etaLabel, timeStr),
 				Size: "md",
 			},
 			&messaging_api.FlexText{
-				Text:  fmt.Sprintf("距離：%s", distanceStr),
+				Text:  fmt.Sprintf("距離：%s（步行約 %d 分鐘）", distanceStr, walkingMinutes),
 				Size:  "sm",
 				Color: "#888888",
 			},
@@ -598,8 +617,47 @@ func (h *Handler) createGarbageTruckBubble(stop *garbage.NearestStop) messaging_
 		},
 	}
 
+	missed := stop.Status == garbage.StopStatusJustLeft || !stop.Catchable(now)
+	if stop.Status != garbage.StopStatusJustLeft && missed {
+		body.Contents = append(body.Contents, &messaging_api.FlexText{
+			Text:  "⚠️ 現在走過去可能趕不上這一班",
+			Size:  "sm",
+			Color: "#E67E22",
+			Wrap:  true,
+		})
+	}
+
+	if stop.Trips > 1 {
+		body.Contents = append(body.Contents, &messaging_api.FlexText{
+			Text:  fmt.Sprintf("🚛 此地點共有 %d 個車次停靠", stop.Trips),
+			Size:  "sm",
+			Color: "#888888",
+		})
+	}
+
+	if stop.Status == garbage.StopStatusJustLeft && stop.NextStop != nil {
+		body.Contents = append(body.Contents, &messaging_api.FlexText{
+			Text:  fmt.Sprintf("➡️ 下一站 %s：%s", garbage.FormatClock(stop.NextStop.Time), stop.NextStop.Name),
+			Size:  "sm",
+			Color: "#E67E22",
+			Wrap:  true,
+		})
+	}
+
+	if stop.Notice != "" {
+		body.Contents = append(body.Contents, &messaging_api.FlexText{
+			Text:  fmt.Sprintf("📅 %s", stop.Notice),
+			Size:  "sm",
+			Color: "#D9534F",
+			Wrap:  true,
+		})
+	}
+
 	favoriteData := fmt.Sprintf("action=add_favorite&lat=%f&lng=%f&name=%s&address=%s", 
 		stop.Stop.Lat, stop.Stop.Lng, stop.Stop.Name, stop.Stop.Name)
+	timelineData := fmt.Sprintf("action=route_timeline&route=%s&stop=%s", stop.Route.ID, stop.Stop.Name)
+	detailsData := fmt.Sprintf("action=stop_details&id=%s", stop.StopID)
+	shareData := fmt.Sprintf("action=share_stop&id=%s", stop.StopID)
 
 	footer := messaging_api.FlexBox{
 		Layout: "vertical",
@@ -625,15 +683,62 @@ func (h *Handler) createGarbageTruckBubble(stop *garbage.NearestStop) messaging_
 					},
 				},
 			},
+			&messaging_api.FlexBox{
+				Layout: "horizontal",
+				Contents: []messaging_api.FlexComponentInterface{
+					&messaging_api.FlexButton{
+						Action: &messaging_api.PostbackAction{
+							Label: "🕒 路線時刻表",
+							Data:  timelineData,
+						},
+						Style: "link",
+					},
+					&messaging_api.FlexButton{
+						Action: &messaging_api.PostbackAction{
+							Label: "📋 站點詳情",
+							Data:  detailsData,
+						},
+						Style: "link",
+					},
+				},
+			},
+			&messaging_api.FlexBox{
+				Layout: "horizontal",
+				Contents: []messaging_api.FlexComponentInterface{
+					&messaging_api.FlexButton{
+						Action: &messaging_api.PostbackAction{
+							Label: "⭐ 收藏此地點",
+							Data:  favoriteData,
+						},
+						Style: "link",
+						Color: "#999999",
+					},
+					&messaging_api.FlexButton{
+						Action: &messaging_api.PostbackAction{
+							Label: "📤 分享位置",
+							Data:  shareData,
+						},
+						Style: "link",
+						Color: "#999999",
+					},
+				},
+			},
+		},
+	}
+
+	// 剛錯過垃圾車時，提供追到後面站點的選項
+	if missed {
+		chaseData := fmt.Sprintf("action=chase&route=%s&stop=%s&lat=%f&lng=%f", stop.Route.ID, stop.Stop.Name, userLat, userLng)
+		footer.Contents = append([]messaging_api.FlexComponentInterface{
 			&messaging_api.FlexButton{
 				Action: &messaging_api.PostbackAction{
-					Label: "⭐ 收藏此地點",
-					Data:  favoriteData,
+					Label: "🏃 追垃圾車",
+					Data:  chaseData,
 				},
-				Style: "link",
-				Color: "#999999",
+				Style: "primary",
+				Color: "#E67E22",
 			},
-		},
+		}, footer.Contents...)
 	}
 
 	return messaging_api.FlexBubble{
@@ -642,6 +747,39 @@ func (h *Handler) createGarbageTruckBubble(stop *garbage.NearestStop) messaging_
 	}
 }
 
+// createStopStatusText shows whether the truck is on its way, at the stop or has
+// just left, along with the arrival–departure window.
+func (h *Handler) createStopStatusText(stop *garbage.NearestStop) *messaging_api.FlexText {
+	now := utils.NowInTaiwan()
+	window := fmt.Sprintf("%s－%s", stop.ETA.Format("15:04"), stop.Departure.Format("15:04"))
+
+	switch stop.Status {
+	case garbage.StopStatusAtStop:
+		return &messaging_api.FlexText{
+			Text:   fmt.Sprintf("🟢 垃圾車在站中，%s 離開", stop.Departure.Format("15:04")),
+			Size:   "sm",
+			Color:  "#1DB446",
+			Weight: "bold",
+			Wrap:   true,
+		}
+	case garbage.StopStatusJustLeft:
+		return &messaging_api.FlexText{
+			Text:   fmt.Sprintf("🔴 垃圾車已於 %s 離開", stop.Departure.Format("15:04")),
+			Size:   "sm",
+			Color:  "#D9534F",
+			Weight: "bold",
+			Wrap:   true,
+		}
+	default:
+		return &messaging_api.FlexText{
+			Text:  fmt.Sprintf("⏳ %s後抵達（停靠 %s）", formatCountdown(stop.ETA.Sub(now)), window),
+			Size:  "sm",
+			Color: "#555555",
+			Wrap:  true,
+		}
+	}
+}
+
 func (h *Handler) handlePostbackEvent(ctx context.Context, event webhook.PostbackEvent) {
 	log.Printf("Processing PostbackEvent")
 	log.Printf("Source type: %T", event.Source)
@@ -671,6 +809,27 @@ func (h *Handler) handlePostbackEvent(ctx context.Context, event webhook.Postbac
 		case "delete_favorite":
 			h.handleDeleteFavoritePostback(ctx, userID, params)
 			return
+		case "route_timeline":
+			h.handleRouteTimelinePostback(ctx, userID, params)
+			return
+		case "stop_details":
+			h.handleStopDetailsPostback(ctx, userID, params)
+			return
+		case "search":
+			h.handleSearchPostback(ctx, userID, params)
+			return
+		case "chase":
+			h.handleChasePostback(ctx, userID, params)
+			return
+		case "set_map_provider":
+			h.handleMapProviderPostback(ctx, userID, params)
+			return
+		case "share_stop":
+			h.handleShareStopPostback(ctx, userID, params)
+			return
+		case "cancel_reminder":
+			h.handleCancelReminderPostback(ctx, userID, params)
+			return
 		}
 	}
 
@@ -684,30 +843,46 @@ func (h *Handler) handlePostbackEvent(ctx context.Context, event webhook.Postbac
 			return
 		}
 
-		etaTime := time.Unix(eta, 0)
-		notificationTime := etaTime.Add(-10 * time.Minute)
-		
-		log.Printf("Creating reminder for user %s: stop=%s, ETA=%s, notificationTime=%s", 
-			userID, stopName, etaTime.Format("2006-01-02 15:04:05"), notificationTime.Format("2006-01-02 15:04:05"))
-		
-		reminder := &store.Reminder{
-			UserID:         userID,
-			StopName:       stopName,
-			RouteID:        routeID,
-			ETA:            etaTime,
-			AdvanceMinutes: 10,
-		}
+		h.createReminder(ctx, userID, routeID, stopName, time.Unix(eta, 0))
+	}
+}
 
-		err = h.store.CreateReminder(ctx, reminder)
+// createReminder saves a reminder for the truck arriving at the stop around eta,
+// moved to the stop's next service day if the truck does not come then.
+func (h *Handler) createReminder(ctx context.Context, userID, routeID, stopName string, etaTime time.Time) {
+	// 依收運日曆重新計算，確保提醒落在垃圾車實際有來的日子
+	if garbageData, err := h.garbageAdapter.GetGarbageData(ctx); err == nil {
+		nextArrival, err := h.garbageAdapter.NextArrivalForStop(garbageData, routeID, stopName, utils.NowInTaiwan())
 		if err != nil {
-			log.Printf("Error creating reminder: %v", err)
-			h.replyMessage(ctx, userID, "提醒設定失敗")
-			return
+			log.Printf("Cannot compute next arrival for route %s stop %s: %v", routeID, stopName, err)
+		} else if !nextArrival.Equal(etaTime) {
+			log.Printf("Adjusting reminder ETA from %s to next service day %s", etaTime.Format("2006-01-02 15:04"), nextArrival.Format("2006-01-02 15:04"))
+			etaTime = nextArrival
 		}
+	}
 
-		log.Printf("Successfully created reminder for user %s, will notify at %s", userID, notificationTime.Format("2006-01-02 15:04:05"))
-		h.replyMessage(ctx, userID, fmt.Sprintf("✅ 已設定提醒！\n將在垃圾車抵達 %s 前 10 分鐘通知您。", stopName))
+	notificationTime := etaTime.Add(-10 * time.Minute)
+	
+	log.Printf("Creating reminder for user %s: stop=%s, ETA=%s, notificationTime=%s", 
+		userID, stopName, etaTime.Format("2006-01-02 15:04:05"), notificationTime.Format("2006-01-02 15:04:05"))
+	
+	reminder := &store.Reminder{
+		UserID:         userID,
+		StopName:       stopName,
+		RouteID:        routeID,
+		ETA:            etaTime,
+		AdvanceMinutes: 10,
 	}
+
+	err := h.store.CreateReminder(ctx, reminder)
+	if err != nil {
+		log.Printf("Error creating reminder: %v", err)
+		h.replyMessage(ctx, userID, "提醒設定失敗")
+		return
+	}
+
+	log.Printf("Successfully created reminder for user %s, will notify at %s", userID, notificationTime.Format("2006-01-02 15:04:05"))
+	h.replyMessage(ctx, userID, fmt.Sprintf("✅ 已設定提醒！\n將在垃圾車 %s 抵達 %s 前 10 分鐘通知您。", formatETA(etaTime), stopName))
 }
 
 func (h *Handler) handleAddFavoritePostback(ctx context.Context, userID string, params map[string]string) {
@@ -729,26 +904,22 @@ func (h *Handler) handleAddFavoritePostback(ctx context.Context, userID string,
 		return
 	}
 
-	// 檢查是否已經收藏過相同地點
-	user, err := h.store.GetUser(ctx, userID)
-	if err == nil {
-		for _, fav := range user.Favorites {
-			// 檢查是否已存在相同名稱或相近位置的收藏
-			if fav.Name == stopName || (math.Abs(fav.Lat-lat) < 0.001 && math.Abs(fav.Lng-lng) < 0.001) {
-				h.replyMessage(ctx, userID, fmt.Sprintf("「%s」已經在您的收藏清單中了！", stopName))
-				return
-			}
-		}
-	}
-
 	// 進行反向地理編碼獲取完整地址
 	location, err := h.geoClient.ReverseGeocode(ctx, lat, lng)
-	var address string
+	var address, geocodedAddress string
 	if err != nil {
 		log.Printf("Reverse geocoding failed: %v", err)
 		address = fmt.Sprintf("緯度 %f, 經度 %f", lat, lng)
 	} else {
 		address = location.Address
+		geocodedAddress = location.Address
+	}
+
+	// 檢查是否已經收藏過相同地點；沒有真正的地址時只比對名稱與座標
+	user, err := h.store.GetUser(ctx, userID)
+	if err == nil && duplicateFavorite(user, stopName, lat, lng, geocodedAddress) != nil {
+		h.replyMessage(ctx, userID, fmt.Sprintf("「%s」已經在您的收藏清單中了！", stopName))
+		return
 	}
 
 	favorite := store.Favorite{
@@ -804,12 +975,20 @@ func (h *Handler) findUserFavoriteByName(ctx context.Context, userID, name strin
 	}
 
 	// 進行模糊匹配收藏地點名稱
-	lowerName := strings.ToLower(strings.TrimSpace(name))
-	for _, fav := range user.Favorites {
-		lowerFavName := strings.ToLower(strings.TrimSpace(fav.Name))
-		// 完全匹配或包含匹配
-		if lowerFavName == lowerName || strings.Contains(lowerFavName, lowerName) || strings.Contains(lowerName, lowerFavName) {
-			return &fav
+	return findFavorite(user, name)
+}
+
+// duplicateFavorite returns the favorite with the same name, the same parsed
+// address or a location within about 100 m, if any.
+func duplicateFavorite(user *store.User, name string, lat, lng float64, address string) *store.Favorite {
+	key := geo.ParseAddress(address).Key()
+	for i := range user.Favorites {
+		fav := &user.Favorites[i]
+		if fav.Name == name || (math.Abs(fav.Lat-lat) < 0.001 && math.Abs(fav.Lng-lng) < 0.001) {
+			return fav
+		}
+		if key != "" && geo.ParseAddress(fav.Address).Key() == key {
+			return fav
 		}
 	}
 	return nil
@@ -822,14 +1001,30 @@ func (h *Handler) addFavorite(ctx context.Context, userID, name, address string)
 		return
 	}
 
-	favorite := store.Favorite{
+	h.saveFavorite(ctx, userID, name, address, store.Favorite{
 		Name:    name,
 		Lat:     location.Lat,
 		Lng:     location.Lng,
 		Address: location.Address,
+	})
+}
+
+// saveFavorite adds the favorite unless the same place is already saved. input
+// is the address as the user wrote it, which may differ from the stored one.
+func (h *Handler) saveFavorite(ctx context.Context, userID, name, input string, favorite store.Favorite) {
+	// 同一個地址的不同寫法（全形數字、台／臺、二段／2段）視為重複收藏
+	if user, err := h.store.GetUser(ctx, userID); err == nil {
+		existing := duplicateFavorite(user, name, favorite.Lat, favorite.Lng, input)
+		if existing == nil {
+			existing = duplicateFavorite(user, name, favorite.Lat, favorite.Lng, favorite.Address)
+		}
+		if existing != nil {
+			h.replyMessage(ctx, userID, fmt.Sprintf("這個地點已經收藏為「%s」了！", existing.Name))
+			return
+		}
 	}
 
-	err = h.store.AddFavorite(ctx, userID, favorite)
+	err := h.store.AddFavorite(ctx, userID, favorite)
 	if err != nil {
 		log.Printf("Error adding favorite: %v", err)
 		h.replyMessage(ctx, userID, "收藏地點失敗")
@@ -1000,29 +1195,13 @@ func (h *Handler) deleteFavorite(ctx context.Context, userID, name string) {
 }
 
 func (h *Handler) extractSimplifiedAddress(text string) string {
-	// 嘗試提取縣市區的模式
-	patterns := []string{
-		`(台北市|新北市|桃園市|台中市|台南市|高雄市|基隆市|新竹市|嘉義市)[^市]*?(區|市)`,
-		`(新竹縣|苗栗縣|彰化縣|南投縣|雲林縣|嘉義縣|屏東縣|宜蘭縣|花蓮縣|台東縣|澎湖縣|金門縣|連江縣)[^縣]*?(鄉|鎮|市)`,
-	}
-	
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		if match := re.FindString(text); match != "" {
-			log.Printf("Extracted simplified address using pattern: %s -> %s", pattern, match)
-			return match
-		}
+	// 只保留縣市與區
+	addr := geo.ParseAddress(text)
+	simplified := addr.City + addr.District
+	if simplified != "" {
+		log.Printf("Extracted simplified address: %s -> %s", text, simplified)
 	}
-	
-	// 如果沒有匹配，嘗試提取縣市
-	cityPattern := `(台北市|新北市|桃園市|台中市|台南市|高雄市|基隆市|新竹市|嘉義市|新竹縣|苗栗縣|彰化縣|南投縣|雲林縣|嘉義縣|屏東縣|宜蘭縣|花蓮縣|台東縣|澎湖縣|金門縣|連江縣)`
-	re := regexp.MustCompile(cityPattern)
-	if match := re.FindString(text); match != "" {
-		log.Printf("Extracted city from address: %s", match)
-		return match
-	}
-	
-	return ""
+	return simplified
 }
 
 func (h *Handler) replyMessage(ctx context.Context, userID, text string) {
@@ -1055,6 +1234,53 @@ func (h *Handler) GetMessagingAPI() *messaging_api.MessagingApiAPI {
 	return h.messagingAPI
 }
 
+// formatCountdown renders a duration as "X 小時 Y 分鐘".
+func formatCountdown(d time.Duration) string {
+	minutes := int(math.Ceil(d.Minutes()))
+	if minutes < 60 {
+		return fmt.Sprintf("%d 分鐘", minutes)
+	}
+	if minutes < 24*60 {
+		return fmt.Sprintf("%d 小時 %d 分鐘", minutes/60, minutes%60)
+	}
+	return fmt.Sprintf("%d 天 %d 小時", minutes/(24*60), minutes%(24*60)/60)
+}
+
+// formatETA shows only the clock time for today and adds the day otherwise,
+// since the next service day may be several days away.
+func formatETA(eta time.Time) string {
+	eta = utils.ToTaiwan(eta)
+	now := utils.NowInTaiwan()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	etaDay := time.Date(eta.Year(), eta.Month(), eta.Day(), 0, 0, 0, 0, now.Location())
+
+	switch days := int(etaDay.Sub(today).Hours() / 24); days {
+	case 0:
+		return eta.Format("15:04")
+	case 1:
+		return "明天 " + eta.Format("15:04")
+	case 2:
+		return "後天 " + eta.Format("15:04")
+	default:
+		weekdays := []string{"日", "一", "二", "三", "四", "五", "六"}
+		return fmt.Sprintf("%d/%d(%s) %s", eta.Month(), eta.Day(), weekdays[eta.Weekday()], eta.Format("15:04"))
+	}
+}
+
+// describeTimeWindow renders a window as "19:00前", "明天 05:00－明天 10:00" and
+// so on, with the day when it is not today.
+func describeTimeWindow(from, to time.Time) string {
+	switch {
+	case !from.IsZero() && !to.IsZero():
+		return fmt.Sprintf("%s－%s", formatETA(from), formatETA(to))
+	case !to.IsZero():
+		return fmt.Sprintf("%s前", formatETA(to))
+	case !from.IsZero():
+		return fmt.Sprintf("%s後", formatETA(from))
+	}
+	return "指定時間內"
+}
+
 func parsePostbackData(data string) map[string]string {
 	params := make(map[string]string)
 	pairs := strings.Split(data, "&")
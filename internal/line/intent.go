@@ -0,0 +1,300 @@
+package line
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/gemini"
+	"linebot-garbage-helper/internal/geo"
+	"linebot-garbage-helper/internal/store"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// maxReminderChoices caps the reminders offered when a cancel is ambiguous.
+const maxReminderChoices = 10
+
+// dispatchIntent handles intents other than finding trucks. It returns false
+// when the message still needs its location resolved, so the caller goes on
+// with the truck search.
+func (h *Handler) dispatchIntent(ctx context.Context, userID string, intent *gemini.IntentResult) bool {
+	switch intent.Intent {
+	case gemini.IntentGreeting:
+		h.replyMessage(ctx, userID, welcomeMessage)
+
+	case gemini.IntentHelp:
+		h.replyMessage(ctx, userID, helpMessage)
+
+	case gemini.IntentListFavorites:
+		h.listFavoritesWithUI(ctx, userID)
+
+	case gemini.IntentAddFavorite:
+		h.addFavoriteFromIntent(ctx, userID, intent.Slots)
+
+	case gemini.IntentDeleteFavorite:
+		if intent.Slots.FavoriteName == "" {
+			h.replyMessage(ctx, userID, "請問要刪除哪一個收藏地點？例如：「刪除收藏 公司」")
+			return true
+		}
+		h.deleteFavorite(ctx, userID, intent.Slots.FavoriteName)
+
+	case gemini.IntentCancelReminder:
+		h.cancelReminders(ctx, userID, intent.Slots)
+
+	case gemini.IntentSetReminder:
+		return h.setReminderFromIntent(ctx, userID, intent)
+
+	case gemini.IntentSortResults:
+		// 訊息中有地點時照一般查詢處理，否則使用最近分享的位置
+		if intent.District != "" {
+			return false
+		}
+		location := h.recentLocation(ctx, userID)
+		if location == nil {
+			h.replyMessage(ctx, userID, "請先分享您的位置，或告訴我地址。")
+			return true
+		}
+		h.searchNearbyGarbageTrucks(ctx, userID, location.Lat, location.Lng, intent)
+
+	default:
+		return false
+	}
+	return true
+}
+
+// recentLocation returns the location the user shared within lastLocationTTL,
+// or nil.
+func (h *Handler) recentLocation(ctx context.Context, userID string) *store.Favorite {
+	user, err := h.store.GetUser(ctx, userID)
+	if err != nil || user.LastLocation == nil || utils.NowInTaiwan().Sub(user.LastLocationAt) > lastLocationTTL {
+		return nil
+	}
+	return user.LastLocation
+}
+
+// setReminderFromIntent sets a reminder for the stop named in the slots or, when
+// none is named, the stop nearest to the location the user last shared. It
+// returns false for a message naming only an area, which is searched so the
+// user can pick a stop from the results.
+func (h *Handler) setReminderFromIntent(ctx context.Context, userID string, intent *gemini.IntentResult) bool {
+	if intent.Slots.Stop == "" && intent.District != "" {
+		h.replyMessage(ctx, userID, "⏰ 請在查詢結果中點選站點的「提醒我」，垃圾車抵達前 10 分鐘會通知您。")
+		return false
+	}
+
+	garbageData, err := h.garbageAdapter.GetGarbageData(ctx)
+	if err != nil {
+		log.Printf("Error fetching garbage data for reminder: %v", err)
+		h.replyMessage(ctx, userID, "提醒設定失敗，請稍後再試。")
+		return true
+	}
+	now := utils.NowInTaiwan()
+
+	var stops []*garbage.NearestStop
+	if intent.Slots.Stop != "" {
+		matches := garbageData.LocationIndex().Search(intent.Slots.Stop)
+		if addr := geo.ParseAddress(intent.Slots.Stop); addr.Road != "" && addr.Number != "" {
+			matches = garbage.MatchesAtAddress(matches, addr)
+		}
+		for _, match := range garbage.BestMatches(matches) {
+			stop, err := h.garbageAdapter.NextVisit(garbageData, match.Group, now)
+			if err != nil {
+				log.Printf("No upcoming visit to %s: %v", match.Group.Name, err)
+				continue
+			}
+			stops = append(stops, stop)
+		}
+		if len(stops) == 0 {
+			h.replyMessage(ctx, userID, fmt.Sprintf("找不到「%s」這個站點，請分享位置後再說「提醒我」。", intent.Slots.Stop))
+			return true
+		}
+	} else {
+		location := h.recentLocation(ctx, userID)
+		if location == nil {
+			h.replyMessage(ctx, userID, "請先分享您的位置，或告訴我要提醒的站點。")
+			return true
+		}
+		stops, err = h.garbageAdapter.FindNearestStops(location.Lat, location.Lng, garbageData, 1, now)
+		if err != nil || len(stops) == 0 {
+			log.Printf("No stop near %f,%f for reminder: %v", location.Lat, location.Lng, err)
+			h.replyMessage(ctx, userID, "附近找不到垃圾車站點，無法設定提醒。")
+			return true
+		}
+	}
+
+	// 同分的站點都可能是使用者說的那一個，讓使用者選擇
+	if len(stops) > 1 {
+		h.sendReminderStopChoices(ctx, userID, stops)
+		return true
+	}
+
+	stop := stops[0]
+	h.createReminder(ctx, userID, stop.Route.ID, stop.Stop.Name, stop.ETA)
+	return true
+}
+
+// sendReminderStopChoices asks which of the stops to be reminded about; each
+// choice sets the reminder directly.
+func (h *Handler) sendReminderStopChoices(ctx context.Context, userID string, stops []*garbage.NearestStop) {
+	var items []messaging_api.QuickReplyItem
+	for _, stop := range stops {
+		label := []rune(fmt.Sprintf("%s %s", formatETA(stop.ETA), stop.Stop.Name))
+		if len(label) > maxQuickReplyLabel {
+			label = label[:maxQuickReplyLabel]
+		}
+		items = append(items, messaging_api.QuickReplyItem{
+			Action: &messaging_api.PostbackAction{
+				Label:       string(label),
+				Data:        fmt.Sprintf("route=%s&stop=%s&eta=%d", stop.Route.ID, stop.Stop.Name, stop.ETA.Unix()),
+				DisplayText: fmt.Sprintf("提醒我 %s", stop.Stop.Name),
+			},
+		})
+	}
+
+	message := &messaging_api.TextMessage{
+		Text:       fmt.Sprintf("有 %d 個站點符合，請問要提醒哪一個？", len(stops)),
+		QuickReply: &messaging_api.QuickReply{Items: items},
+	}
+	h.sendMessage(ctx, userID, message)
+}
+
+// addFavoriteFromIntent saves the address in the message or, for "這裡", the
+// location the user last shared.
+func (h *Handler) addFavoriteFromIntent(ctx context.Context, userID string, slots gemini.IntentSlots) {
+	name := strings.TrimSpace(slots.FavoriteName)
+	if name == "" {
+		h.replyMessage(ctx, userID, "請問要收藏成什麼名稱？例如：「把這裡存成公司」")
+		return
+	}
+
+	if slots.Address != "" {
+		h.addFavorite(ctx, userID, name, slots.Address)
+		return
+	}
+
+	location := h.recentLocation(ctx, userID)
+	if location == nil {
+		h.replyMessage(ctx, userID, fmt.Sprintf("請先分享您目前的位置，再說「把這裡存成%s」。", name))
+		return
+	}
+	h.saveFavorite(ctx, userID, name, location.Address, store.Favorite{
+		Name:    name,
+		Lat:     location.Lat,
+		Lng:     location.Lng,
+		Address: location.Address,
+	})
+}
+
+// cancelReminders cancels the reminders matching the day and stop in the
+// slots. When nothing narrows down several reminders, the user picks one.
+func (h *Handler) cancelReminders(ctx context.Context, userID string, slots gemini.IntentSlots) {
+	reminders, err := h.store.GetUserActiveReminders(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting reminders for user %s: %v", userID, err)
+		h.replyMessage(ctx, userID, "無法取得提醒清單，請稍後再試。")
+		return
+	}
+
+	today := utils.NowInTaiwan()
+	var matched []*store.Reminder
+	for _, reminder := range reminders {
+		eta := utils.ToTaiwan(reminder.ETA)
+		switch slots.Day {
+		case "today":
+			if !sameDay(eta, today) {
+				continue
+			}
+		case "tomorrow":
+			if !sameDay(eta, today.AddDate(0, 0, 1)) {
+				continue
+			}
+		}
+		if slots.Stop != "" && !strings.Contains(reminder.StopName, slots.Stop) {
+			continue
+		}
+		matched = append(matched, reminder)
+	}
+
+	if len(matched) == 0 {
+		h.replyMessage(ctx, userID, "沒有符合的提醒。")
+		return
+	}
+
+	if len(matched) > 1 && slots.Day == "" && slots.Stop == "" {
+		h.sendReminderChoices(ctx, userID, matched)
+		return
+	}
+
+	var lines []string
+	for _, reminder := range matched {
+		if err := h.store.UpdateReminderStatus(ctx, reminder.ID, "cancelled"); err != nil {
+			log.Printf("Error cancelling reminder %s: %v", reminder.ID, err)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("・%s %s", formatETA(reminder.ETA), reminder.StopName))
+	}
+	if len(lines) == 0 {
+		h.replyMessage(ctx, userID, "取消提醒失敗，請稍後再試。")
+		return
+	}
+	h.replyMessage(ctx, userID, fmt.Sprintf("✅ 已取消 %d 個提醒：\n%s", len(lines), strings.Join(lines, "\n")))
+}
+
+func (h *Handler) sendReminderChoices(ctx context.Context, userID string, reminders []*store.Reminder) {
+	var items []messaging_api.QuickReplyItem
+	for i, reminder := range reminders {
+		if i >= maxReminderChoices {
+			break
+		}
+		label := []rune(fmt.Sprintf("%s %s", formatETA(reminder.ETA), reminder.StopName))
+		if len(label) > maxQuickReplyLabel {
+			label = label[:maxQuickReplyLabel]
+		}
+		items = append(items, messaging_api.QuickReplyItem{
+			Action: &messaging_api.PostbackAction{
+				Label:       string(label),
+				Data:        fmt.Sprintf("action=cancel_reminder&id=%s", reminder.ID),
+				DisplayText: fmt.Sprintf("取消 %s 的提醒", reminder.StopName),
+			},
+		})
+	}
+
+	message := &messaging_api.TextMessage{
+		Text:       fmt.Sprintf("您有 %d 個提醒，請問要取消哪一個？", len(reminders)),
+		QuickReply: &messaging_api.QuickReply{Items: items},
+	}
+	h.sendMessage(ctx, userID, message)
+}
+
+func (h *Handler) handleCancelReminderPostback(ctx context.Context, userID string, params map[string]string) {
+	reminders, err := h.store.GetUserActiveReminders(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting reminders for user %s: %v", userID, err)
+		h.replyMessage(ctx, userID, "無法取得提醒清單，請稍後再試。")
+		return
+	}
+
+	// 只取消屬於這位使用者、仍有效的提醒
+	for _, reminder := range reminders {
+		if reminder.ID != params["id"] {
+			continue
+		}
+		if err := h.store.UpdateReminderStatus(ctx, reminder.ID, "cancelled"); err != nil {
+			log.Printf("Error cancelling reminder %s: %v", reminder.ID, err)
+			h.replyMessage(ctx, userID, "取消提醒失敗，請稍後再試。")
+			return
+		}
+		h.replyMessage(ctx, userID, fmt.Sprintf("✅ 已取消 %s %s 的提醒。", formatETA(reminder.ETA), reminder.StopName))
+		return
+	}
+	h.replyMessage(ctx, userID, "這個提醒已經取消或已通知過了。")
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
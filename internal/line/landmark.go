@@ -0,0 +1,82 @@
+package line
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/gemini"
+	"linebot-garbage-helper/internal/geo"
+)
+
+// maxQuickReplyLabel is the longest quick reply label LINE accepts.
+const maxQuickReplyLabel = 20
+
+// searchLocationName looks the text up among the stop location names, such as
+// "全家 復興店" or "仁愛路二段 27巷口", which rarely geocode well. It returns false
+// when no location matches, so the caller can fall back to geocoding.
+func (h *Handler) searchLocationName(ctx context.Context, userID, text string, intent *gemini.IntentResult) bool {
+	garbageData, err := h.garbageAdapter.GetGarbageData(ctx)
+	if err != nil {
+		log.Printf("Error fetching garbage data for location name search: %v", err)
+		return false
+	}
+
+	matches := garbageData.LocationIndex().Search(text)
+
+	// 完整的門牌地址只接受同一門牌的站點，其他只是共用路名的站點，交給地理編碼
+	if addr := geo.ParseAddress(text); addr.Road != "" && addr.Number != "" {
+		matches = garbage.MatchesAtAddress(matches, addr)
+	}
+	if len(matches) == 0 {
+		return false
+	}
+
+	// 同分的地點都可能是使用者要找的，讓使用者選擇
+	best := garbage.BestMatches(matches)
+
+	log.Printf("Location name '%s' matched %d stops for user %s, best %s (score %.2f)",
+		text, len(matches), userID, best[0].Group.Name, best[0].Score)
+
+	if len(best) > 1 {
+		h.sendLocationChoices(ctx, userID, text, best, intent)
+		return true
+	}
+
+	h.runStopQuery(ctx, userID, h.locationQuery(best[0].Group, intent))
+	return true
+}
+
+// locationQuery searches around the matched stop.
+func (h *Handler) locationQuery(group *garbage.StopGroup, intent *gemini.IntentResult) stopQuery {
+	query := stopQuery{Lat: group.Lat, Lng: group.Lng, Sort: garbage.SortBest}
+	h.applyIntent(&query, intent)
+	return query
+}
+
+func (h *Handler) sendLocationChoices(ctx context.Context, userID, text string, matches []garbage.LocationMatch, intent *gemini.IntentResult) {
+	var items []messaging_api.QuickReplyItem
+	for _, match := range matches {
+		label := match.Group.Name
+		if runes := []rune(label); len(runes) > maxQuickReplyLabel {
+			label = string(runes[:maxQuickReplyLabel-1]) + "…"
+		}
+
+		items = append(items, messaging_api.QuickReplyItem{
+			Action: &messaging_api.PostbackAction{
+				Label:       label,
+				Data:        h.locationQuery(match.Group, intent).postbackData(),
+				DisplayText: match.Group.Name,
+			},
+		})
+	}
+
+	message := &messaging_api.TextMessage{
+		Text:       fmt.Sprintf("「%s」符合 %d 個站點，請選擇您要查詢的地點：", text, len(matches)),
+		QuickReply: &messaging_api.QuickReply{Items: items},
+	}
+	h.sendMessage(ctx, userID, message)
+}
@@ -0,0 +1,103 @@
+package line
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
+
+	"linebot-garbage-helper/internal/geo"
+)
+
+// mapProvider returns the user's preferred map app, Google Maps when none is
+// set or the user cannot be loaded.
+func (h *Handler) mapProvider(ctx context.Context, userID string) geo.MapProvider {
+	user, err := h.store.GetUser(ctx, userID)
+	if err != nil {
+		return geo.MapProviderGoogle
+	}
+	if provider, ok := geo.ParseMapProvider(user.MapProvider); ok {
+		return provider
+	}
+	return geo.MapProviderGoogle
+}
+
+// handleMapCommand sets the preferred map app, or asks for one when the
+// command has no argument, e.g. "/map apple".
+func (h *Handler) handleMapCommand(ctx context.Context, userID string, args []string) {
+	if len(args) > 0 {
+		provider, ok := geo.ParseMapProvider(args[0])
+		if !ok {
+			h.replyMessage(ctx, userID, "不支援這個地圖，請使用：/map google、/map apple 或 /map osm")
+			return
+		}
+		h.setMapProvider(ctx, userID, provider)
+		return
+	}
+
+	current := h.mapProvider(ctx, userID)
+	var items []messaging_api.QuickReplyItem
+	for _, provider := range geo.MapProviders {
+		label := provider.Name()
+		if provider == current {
+			label = "✓ " + label
+		}
+		items = append(items, messaging_api.QuickReplyItem{
+			Action: &messaging_api.PostbackAction{
+				Label:       label,
+				Data:        fmt.Sprintf("action=set_map_provider&provider=%s", provider),
+				DisplayText: fmt.Sprintf("導航使用 %s", provider.Name()),
+			},
+		})
+	}
+
+	message := &messaging_api.TextMessage{
+		Text:       fmt.Sprintf("🗺 目前導航使用 %s，請選擇要使用的地圖：", current.Name()),
+		QuickReply: &messaging_api.QuickReply{Items: items},
+	}
+	h.sendMessage(ctx, userID, message)
+}
+
+func (h *Handler) handleMapProviderPostback(ctx context.Context, userID string, params map[string]string) {
+	provider, ok := geo.ParseMapProvider(params["provider"])
+	if !ok {
+		h.replyMessage(ctx, userID, "不支援這個地圖，請重新選擇。")
+		return
+	}
+	h.setMapProvider(ctx, userID, provider)
+}
+
+func (h *Handler) setMapProvider(ctx context.Context, userID string, provider geo.MapProvider) {
+	if err := h.store.UpdateMapProvider(ctx, userID, string(provider)); err != nil {
+		log.Printf("Error updating map provider for user %s: %v", userID, err)
+		h.replyMessage(ctx, userID, "設定失敗，請稍後再試。")
+		return
+	}
+	h.replyMessage(ctx, userID, fmt.Sprintf("✅ 之後的導航連結會以 %s 開啟步行路線。", provider.Name()))
+}
+
+// handleShareStopPostback sends the stop as a LINE location message, which can
+// be forwarded to family or opened in any map app.
+func (h *Handler) handleShareStopPostback(ctx context.Context, userID string, params map[string]string) {
+	garbageData, err := h.garbageAdapter.GetGarbageData(ctx)
+	if err != nil {
+		log.Printf("Error fetching garbage data for stop location: %v", err)
+		h.replyMessage(ctx, userID, "抱歉，無法取得垃圾車資料。")
+		return
+	}
+
+	group, ok := garbageData.StopGroups()[params["id"]]
+	if !ok {
+		h.replyMessage(ctx, userID, "抱歉，找不到這個站點的資料，資料可能已更新，請重新查詢。")
+		return
+	}
+
+	message := &messaging_api.LocationMessage{
+		Title:     fmt.Sprintf("🚛 %s", group.Name),
+		Address:   fmt.Sprintf("%s%s %s", group.City, group.District, group.Name),
+		Latitude:  group.Lat,
+		Longitude: group.Lng,
+	}
+	h.sendMessage(ctx, userID, message)
+}
@@ -0,0 +1,157 @@
+package line
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
+
+	"linebot-garbage-helper/internal/garbage"
+)
+
+// routeTimelineRadius is how many stops before and after the selected stop are shown.
+const routeTimelineRadius = 3
+
+func (h *Handler) handleRouteTimelinePostback(ctx context.Context, userID string, params map[string]string) {
+	routeID := params["route"]
+	stopName := params["stop"]
+
+	garbageData, err := h.garbageAdapter.GetGarbageData(ctx)
+	if err != nil {
+		log.Printf("Error fetching garbage data for route timeline: %v", err)
+		h.replyMessage(ctx, userID, "抱歉，無法取得垃圾車資料。")
+		return
+	}
+
+	route := h.garbageAdapter.GetRouteByID(garbageData, routeID)
+	if route == nil || len(route.Stops) == 0 {
+		h.replyMessage(ctx, userID, "抱歉，找不到這條路線的資料。")
+		return
+	}
+
+	bubble := h.createRouteTimelineBubble(route, stopName)
+	flexMessage := messaging_api.FlexMessage{
+		AltText:  fmt.Sprintf("%s 路線時刻表", route.Name),
+		Contents: &bubble,
+	}
+
+	h.sendMessage(ctx, userID, &flexMessage)
+}
+
+func (h *Handler) createRouteTimelineBubble(route *garbage.Route, stopName string) messaging_api.FlexBubble {
+	selected := route.StopIndex(stopName)
+
+	from, to := 0, len(route.Stops)-1
+	if selected >= 0 {
+		from = max(0, selected-routeTimelineRadius)
+		to = min(len(route.Stops)-1, selected+routeTimelineRadius)
+	} else {
+		to = min(len(route.Stops)-1, 2*routeTimelineRadius)
+	}
+
+	subtitle := fmt.Sprintf("車號 %s・共 %d 站", route.VehicleNumber, len(route.Stops))
+	if route.Trip != "" {
+		subtitle = fmt.Sprintf("車號 %s・%s・共 %d 站", route.VehicleNumber, route.Trip, len(route.Stops))
+	}
+
+	contents := []messaging_api.FlexComponentInterface{
+		&messaging_api.FlexText{
+			Text:   fmt.Sprintf("🚛 %s", route.Name),
+			Weight: "bold",
+			Size:   "lg",
+			Wrap:   true,
+		},
+		&messaging_api.FlexText{
+			Text:  subtitle,
+			Size:  "xs",
+			Color: "#888888",
+		},
+		&messaging_api.FlexSeparator{
+			Margin: "md",
+		},
+	}
+
+	if from > 0 {
+		contents = append(contents, &messaging_api.FlexText{
+			Text:   fmt.Sprintf("⋯ 前面還有 %d 站", from),
+			Size:   "xs",
+			Color:  "#aaaaaa",
+			Margin: "sm",
+		})
+	}
+
+	for i := from; i <= to; i++ {
+		contents = append(contents, h.createRouteTimelineRow(route.Stops[i], i == selected))
+	}
+
+	if to < len(route.Stops)-1 {
+		contents = append(contents, &messaging_api.FlexText{
+			Text:   fmt.Sprintf("⋯ 後面還有 %d 站", len(route.Stops)-1-to),
+			Size:   "xs",
+			Color:  "#aaaaaa",
+			Margin: "sm",
+		})
+	}
+
+	if previous, next := route.Neighbors(stopName); previous != nil || next != nil {
+		contents = append(contents, &messaging_api.FlexSeparator{Margin: "md"})
+		if previous != nil {
+			contents = append(contents, &messaging_api.FlexText{
+				Text:   fmt.Sprintf("⬆️ 上一站 %s：%s", garbage.FormatClock(previous.Time), previous.Name),
+				Size:   "xs",
+				Color:  "#666666",
+				Wrap:   true,
+				Margin: "md",
+			})
+		}
+		if next != nil {
+			contents = append(contents, &messaging_api.FlexText{
+				Text:  fmt.Sprintf("⬇️ 下一站 %s：%s", garbage.FormatClock(next.Time), next.Name),
+				Size:  "xs",
+				Color: "#666666",
+				Wrap:  true,
+			})
+		}
+	}
+
+	return messaging_api.FlexBubble{
+		Body: &messaging_api.FlexBox{
+			Layout:   "vertical",
+			Contents: contents,
+		},
+	}
+}
+
+func (h *Handler) createRouteTimelineRow(stop garbage.Stop, selected bool) *messaging_api.FlexBox {
+	color := "#555555"
+	weight := messaging_api.FlexTextWEIGHT_REGULAR
+	name := stop.Name
+	if selected {
+		color = "#1DB446"
+		weight = messaging_api.FlexTextWEIGHT_BOLD
+		name = "📍 " + name
+	}
+
+	return &messaging_api.FlexBox{
+		Layout: "horizontal",
+		Margin: "sm",
+		Contents: []messaging_api.FlexComponentInterface{
+			&messaging_api.FlexText{
+				Text:   garbage.FormatClock(stop.Time),
+				Size:   "sm",
+				Color:  color,
+				Weight: weight,
+				Flex:   1,
+			},
+			&messaging_api.FlexText{
+				Text:   name,
+				Size:   "sm",
+				Color:  color,
+				Weight: weight,
+				Wrap:   true,
+				Flex:   4,
+			},
+		},
+	}
+}
@@ -0,0 +1,332 @@
+package line
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/gemini"
+	"linebot-garbage-helper/internal/geo"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// defaultSearchRadius bounds searches when no radius is configured.
+const defaultSearchRadius = 2000.0
+
+// fallbackNearestStops is how many nearest stops are returned when nothing is
+// found within the search radius or time window.
+const fallbackNearestStops = 5
+
+// resultsPageSize is how many stop bubbles are shown per page of results.
+const resultsPageSize = 5
+
+var sortModeLabels = []struct {
+	mode  garbage.SortMode
+	label string
+}{
+	{garbage.SortBest, "⭐ 最佳"},
+	{garbage.SortSoonest, "⏱ 最快抵達"},
+	{garbage.SortClosest, "📍 最近"},
+}
+
+// stopQuery is a stop search that can be encoded into postback data and re-run,
+// e.g. when the user switches the sort order.
+type stopQuery struct {
+	Lat  float64
+	Lng  float64
+	From time.Time
+	To   time.Time
+	Sort garbage.SortMode
+	// Area limits the search to a gazetteer area; Lat/Lng are then its centroid
+	Area string
+	// Cursor is the position of the first result on the page
+	Cursor int
+	// At is when the first page was ranked; later pages rank against the same
+	// time so the order, and with it the cursor, stays stable
+	At time.Time
+}
+
+// origin is where walking directions start. Area searches have no user
+// location, only the area centroid, so navigation starts from the device.
+func (q stopQuery) origin() *geo.Point {
+	if q.Area != "" {
+		return nil
+	}
+	return &geo.Point{Lat: q.Lat, Lng: q.Lng}
+}
+
+func (q stopQuery) postbackData() string {
+	data := fmt.Sprintf("action=search&lat=%f&lng=%f&sort=%s", q.Lat, q.Lng, q.Sort)
+	if !q.From.IsZero() {
+		data += fmt.Sprintf("&from=%d", q.From.Unix())
+	}
+	if !q.To.IsZero() {
+		data += fmt.Sprintf("&to=%d", q.To.Unix())
+	}
+	if q.Area != "" {
+		data += "&area=" + q.Area
+	}
+	if q.Cursor > 0 {
+		data += fmt.Sprintf("&cursor=%d", q.Cursor)
+	}
+	if !q.At.IsZero() {
+		data += fmt.Sprintf("&at=%d", q.At.Unix())
+	}
+	return data
+}
+
+func parseStopQuery(params map[string]string) (stopQuery, error) {
+	lat, err := strconv.ParseFloat(params["lat"], 64)
+	if err != nil {
+		return stopQuery{}, fmt.Errorf("invalid lat: %s", params["lat"])
+	}
+	lng, err := strconv.ParseFloat(params["lng"], 64)
+	if err != nil {
+		return stopQuery{}, fmt.Errorf("invalid lng: %s", params["lng"])
+	}
+
+	query := stopQuery{Lat: lat, Lng: lng, Sort: garbage.ParseSortMode(params["sort"]), Area: params["area"]}
+	if from, err := strconv.ParseInt(params["from"], 10, 64); err == nil {
+		query.From = time.Unix(from, 0)
+	}
+	if to, err := strconv.ParseInt(params["to"], 10, 64); err == nil {
+		query.To = time.Unix(to, 0)
+	}
+	if cursor, err := strconv.Atoi(params["cursor"]); err == nil && cursor > 0 {
+		query.Cursor = cursor
+	}
+	if at, err := strconv.ParseInt(params["at"], 10, 64); err == nil {
+		query.At = utils.ToTaiwan(time.Unix(at, 0))
+	}
+	return query, nil
+}
+
+// SetSearchRadius sets how far from the user stops are searched, in meters.
+func (h *Handler) SetSearchRadius(meters float64) {
+	h.searchRadius = meters
+}
+
+func (h *Handler) handleSearchPostback(ctx context.Context, userID string, params map[string]string) {
+	query, err := parseStopQuery(params)
+	if err != nil {
+		log.Printf("Invalid search postback: %v", err)
+		h.replyMessage(ctx, userID, "抱歉，查詢條件有誤，請重新查詢。")
+		return
+	}
+
+	h.runStopQuery(ctx, userID, query)
+}
+
+func (h *Handler) runStopQuery(ctx context.Context, userID string, query stopQuery) {
+	garbageData, err := h.garbageAdapter.GetGarbageData(ctx)
+	if err != nil {
+		log.Printf("Error fetching garbage data for user %s: %v", userID, err)
+		h.replyMessage(ctx, userID, "抱歉，無法取得垃圾車資料。")
+		return
+	}
+
+	log.Printf("Using garbage data snapshot from %s, %d collection points available",
+		h.garbageAdapter.LastLoaded().Format(time.RFC3339), len(garbageData.Result.Results))
+
+	// 不在任何縣市資料範圍內時，最近的站點可能在幾百公里外，改為說明支援的縣市
+	if query.Area == "" && !garbageData.Coverage().Covers(query.Lat, query.Lng) {
+		log.Printf("Coordinates lat=%f, lng=%f for user %s are outside the service coverage", query.Lat, query.Lng, userID)
+		h.replyMessage(ctx, userID, outOfCoverageMessage(garbageData, query.Lat, query.Lng))
+		return
+	}
+
+	// 之後的分頁沿用第一頁的時間，排序與游標才不會因時間經過而錯位
+	if query.At.IsZero() {
+		query.At = utils.NowInTaiwan()
+	}
+
+	stops, fallback, err := h.findStops(garbageData, query)
+	if err != nil {
+		log.Printf("Error finding stops for user %s: %v", userID, err)
+		h.replyMessage(ctx, userID, "抱歉，無法找到附近的垃圾車站點。")
+		return
+	}
+
+	if len(stops) == 0 {
+		log.Printf("No garbage truck stops found for user %s at coordinates lat=%f, lng=%f", userID, query.Lat, query.Lng)
+		h.replyMessage(ctx, userID, "附近沒有找到垃圾車站點。")
+		return
+	}
+
+	if query.Cursor >= len(stops) {
+		h.replyMessage(ctx, userID, "沒有更多站點了。")
+		return
+	}
+
+	garbage.RankStops(stops, query.Sort, query.At)
+
+	// 時間範圍內沒有站點時改列最近的站點，先說明這些班次不在指定的時間內
+	hasWindow := !query.From.IsZero() || !query.To.IsZero()
+	if fallback && hasWindow && query.Cursor == 0 {
+		h.replyMessage(ctx, userID, fmt.Sprintf("⚠️ %s沒有符合的垃圾車，以下改列最近的站點，抵達時間不在這個範圍內。", describeTimeWindow(query.From, query.To)))
+	}
+
+	log.Printf("Sending garbage truck results %d-%d of %d to user %s, sort=%s",
+		query.Cursor+1, min(query.Cursor+resultsPageSize, len(stops)), len(stops), userID, query.Sort)
+	h.sendGarbageTruckResults(ctx, userID, stops, query, fallback)
+}
+
+// isCovered reports whether the coordinates are inside the service coverage. It
+// is true when the data cannot be loaded, so callers do not block on it.
+func (h *Handler) isCovered(ctx context.Context, lat, lng float64) bool {
+	garbageData, err := h.garbageAdapter.GetGarbageData(ctx)
+	if err != nil {
+		return true
+	}
+	return garbageData.Coverage().Covers(lat, lng)
+}
+
+// outOfCoverageMessage lists the supported cities and how far the closest
+// covered stop is.
+func outOfCoverageMessage(data *garbage.GarbageData, lat, lng float64) string {
+	message := "📍 這個位置目前不在服務範圍內，附近沒有垃圾車資料。"
+
+	if nearest := data.Index().Nearest(lat, lng, 1, nil); len(nearest) > 0 {
+		point := data.Result.Results[nearest[0].Index]
+		message += fmt.Sprintf("\n\n最近的服務地區是%s%s，距離%s。", point.City, point.District, geo.FormatDistance(nearest[0].Distance))
+	}
+
+	if cities := data.Coverage().Cities(); len(cities) > 0 {
+		message += fmt.Sprintf("\n\n目前支援的縣市：%s", strings.Join(cities, "、"))
+	}
+	return message
+}
+
+// applyTimeWindow limits the query to the time window asked for in the intent.
+func (h *Handler) applyTimeWindow(query *stopQuery, intent *gemini.IntentResult) {
+	if intent == nil || (intent.TimeWindow.From == "" && intent.TimeWindow.To == "") {
+		return
+	}
+
+	log.Printf("Time window query detected: from=%s, to=%s", intent.TimeWindow.From, intent.TimeWindow.To)
+	fromTime, toTime, err := h.analyzer.ParseTimeWindow(intent.TimeWindow)
+	if err != nil {
+		log.Printf("Error parsing time window: %v", err)
+		return
+	}
+
+	log.Printf("Parsed time window: from=%v, to=%v", fromTime, toTime)
+	query.From, query.To = fromTime, toTime
+	// 有指定時間時，使用者通常想知道最快能倒垃圾的班次
+	query.Sort = garbage.SortSoonest
+}
+
+// applyIntent limits the query to the intent's time window and applies the
+// ordering asked for, e.g. 最早 in "全家復興店最早幾點來".
+func (h *Handler) applyIntent(query *stopQuery, intent *gemini.IntentResult) {
+	h.applyTimeWindow(query, intent)
+	if intent != nil && intent.Slots.Sort != "" {
+		query.Sort = garbage.ParseSortMode(intent.Slots.Sort)
+	}
+}
+
+// findStops returns the stops within the search radius, or the query's area, and
+// time window, falling back to the nearest stops when none match. fallback
+// reports that the nearest stops were returned, without the query's time window
+// and only as many as the current page needs.
+func (h *Handler) findStops(data *garbage.GarbageData, query stopQuery) (stops []*garbage.NearestStop, fallback bool, err error) {
+	window := garbage.TimeWindow{From: query.From, To: query.To}
+
+	if query.Area != "" {
+		area, ok := data.Gazetteer().Area(query.Area)
+		if !ok {
+			return nil, false, fmt.Errorf("area not found: %s", query.Area)
+		}
+
+		stops, err = h.garbageAdapter.FindStopsInArea(data, area, window, query.At)
+		if err != nil {
+			return nil, false, err
+		}
+		log.Printf("Found %d stops in area %s matching time window", len(stops), area.ID)
+	} else {
+		radius := h.searchRadius
+		if radius <= 0 {
+			radius = defaultSearchRadius
+		}
+
+		stops, err = h.garbageAdapter.FindStopsInTimeWindow(query.Lat, query.Lng, data, window, radius, query.At)
+		if err != nil {
+			return nil, false, err
+		}
+		log.Printf("Found %d stops within %.0fm matching time window", len(stops), radius)
+	}
+
+	if len(stops) > 0 {
+		return stops, false, nil
+	}
+
+	log.Printf("No stops found in search radius or time window, searching for nearest stops")
+	limit := max(fallbackNearestStops, query.Cursor+resultsPageSize+1)
+	stops, err = h.garbageAdapter.FindNearestStops(query.Lat, query.Lng, data, limit, query.At)
+	return stops, true, err
+}
+
+// sortQuickReply offers the other sort orders for the same query.
+func (h *Handler) sortQuickReply(query stopQuery) *messaging_api.QuickReply {
+	var items []messaging_api.QuickReplyItem
+	for _, option := range sortModeLabels {
+		label := option.label
+		if option.mode == query.Sort {
+			label = "✓ " + label
+		}
+
+		// 換排序會從第一頁重新開始，以當下時間重新排序
+		next := query
+		next.Sort = option.mode
+		next.Cursor = 0
+		next.At = time.Time{}
+		items = append(items, messaging_api.QuickReplyItem{
+			Action: &messaging_api.PostbackAction{
+				Label:       label,
+				Data:        next.postbackData(),
+				DisplayText: fmt.Sprintf("依「%s」排序", option.label),
+			},
+		})
+	}
+
+	return &messaging_api.QuickReply{Items: items}
+}
+
+// moreResultsBubble links to the next page of the same query. remaining is zero
+// when the number of further stops is not known.
+func (h *Handler) moreResultsBubble(next stopQuery, remaining int) messaging_api.FlexBubble {
+	text := "還有更多站點"
+	if remaining > 0 {
+		text = fmt.Sprintf("還有 %d 個站點", remaining)
+	}
+
+	return messaging_api.FlexBubble{
+		Body: &messaging_api.FlexBox{
+			Layout:         "vertical",
+			JustifyContent: "center",
+			Contents: []messaging_api.FlexComponentInterface{
+				&messaging_api.FlexText{
+					Text:  text,
+					Size:  "md",
+					Align: "center",
+					Color: "#555555",
+				},
+				&messaging_api.FlexButton{
+					Action: &messaging_api.PostbackAction{
+						Label:       "顯示更多",
+						Data:        next.postbackData(),
+						DisplayText: "顯示更多站點",
+					},
+					Style:  "primary",
+					Margin: "lg",
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,122 @@
+package line
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// maxStopDetailTrips caps the trips listed in one stop details bubble.
+const maxStopDetailTrips = 8
+
+func (h *Handler) handleStopDetailsPostback(ctx context.Context, userID string, params map[string]string) {
+	garbageData, err := h.garbageAdapter.GetGarbageData(ctx)
+	if err != nil {
+		log.Printf("Error fetching garbage data for stop details: %v", err)
+		h.replyMessage(ctx, userID, "抱歉，無法取得垃圾車資料。")
+		return
+	}
+
+	group, ok := garbageData.StopGroups()[params["id"]]
+	if !ok || len(group.Points) == 0 {
+		h.replyMessage(ctx, userID, "抱歉，找不到這個站點的資料，資料可能已更新，請重新查詢。")
+		return
+	}
+
+	bubble := h.createStopDetailsBubble(group)
+	flexMessage := messaging_api.FlexMessage{
+		AltText:  fmt.Sprintf("%s 站點詳情", group.Name),
+		Contents: &bubble,
+	}
+
+	h.sendMessage(ctx, userID, &flexMessage)
+}
+
+func (h *Handler) createStopDetailsBubble(group *garbage.StopGroup) messaging_api.FlexBubble {
+	contents := []messaging_api.FlexComponentInterface{
+		&messaging_api.FlexText{
+			Text:   fmt.Sprintf("📍 %s", group.Name),
+			Weight: "bold",
+			Size:   "lg",
+			Wrap:   true,
+		},
+		&messaging_api.FlexText{
+			Text:  fmt.Sprintf("%s%s・共 %d 個車次", group.City, group.District, len(group.Points)),
+			Size:  "xs",
+			Color: "#888888",
+		},
+	}
+
+	now := utils.NowInTaiwan()
+	calendar := h.garbageAdapter.Calendar()
+
+	for i, point := range group.Points {
+		if i == maxStopDetailTrips {
+			contents = append(contents, &messaging_api.FlexText{
+				Text:   fmt.Sprintf("⋯ 還有 %d 個車次", len(group.Points)-maxStopDetailTrips),
+				Size:   "xs",
+				Color:  "#aaaaaa",
+				Margin: "md",
+			})
+			break
+		}
+
+		window := garbage.FormatClock(point.ArrivalTime)
+		if point.DepartureTime != "" {
+			window = fmt.Sprintf("%s－%s", garbage.FormatClock(point.ArrivalTime), garbage.FormatClock(point.DepartureTime))
+		}
+
+		vehicle := fmt.Sprintf("車號 %s", point.VehicleNumber)
+		if point.VehicleTrip != "" {
+			vehicle = fmt.Sprintf("車號 %s・%s", point.VehicleNumber, point.VehicleTrip)
+		}
+		if point.Squad != "" {
+			vehicle = fmt.Sprintf("%s・%s", vehicle, point.Squad)
+		}
+
+		next := "近期無收運"
+		if arrival, err := calendar.NextArrival(point, now); err == nil {
+			next = "下一班 " + formatETA(arrival)
+		}
+
+		contents = append(contents,
+			&messaging_api.FlexSeparator{Margin: "md"},
+			&messaging_api.FlexText{
+				Text:   fmt.Sprintf("🕒 %s", window),
+				Weight: "bold",
+				Size:   "md",
+				Margin: "md",
+			},
+			&messaging_api.FlexText{
+				Text:  fmt.Sprintf("路線：%s", point.Route),
+				Size:  "sm",
+				Color: "#555555",
+				Wrap:  true,
+			},
+			&messaging_api.FlexText{
+				Text:  vehicle,
+				Size:  "sm",
+				Color: "#888888",
+				Wrap:  true,
+			},
+			&messaging_api.FlexText{
+				Text:  fmt.Sprintf("收運日：%s・%s", calendar.ServiceDays(point), next),
+				Size:  "xs",
+				Color: "#888888",
+				Wrap:  true,
+			},
+		)
+	}
+
+	return messaging_api.FlexBubble{
+		Body: &messaging_api.FlexBox{
+			Layout:   "vertical",
+			Contents: contents,
+		},
+	}
+}
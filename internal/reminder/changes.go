@@ -0,0 +1,274 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/geo"
+	"linebot-garbage-helper/internal/store"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// favoriteImpactRadius is how close a changed stop must be to a favorite for its owner to be notified.
+const favoriteImpactRadius = 300.0
+
+// maxChangeLines caps the number of changes listed in one notice.
+const maxChangeLines = 8
+
+// ChangeNotifier compares each refreshed dataset with the previous snapshot and
+// tells users whose favorites or reminders are affected. The previous snapshot
+// is persisted per route so changes made while the server was down are caught
+// on the next start.
+type ChangeNotifier struct {
+	store        *store.FirestoreClient
+	messagingAPI *messaging_api.MessagingApiAPI
+
+	mu       sync.Mutex
+	previous map[string]*garbage.Route
+}
+
+func NewChangeNotifier(store *store.FirestoreClient, messagingAPI *messaging_api.MessagingApiAPI) *ChangeNotifier {
+	return &ChangeNotifier{
+		store:        store,
+		messagingAPI: messagingAPI,
+	}
+}
+
+// HandleRefresh is a garbage.RefreshHook.
+func (cn *ChangeNotifier) HandleRefresh(ctx context.Context, data *garbage.GarbageData) {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+
+	current := data.Routes()
+
+	if cn.previous == nil {
+		previous, err := cn.loadSnapshot(ctx)
+		if err != nil {
+			log.Printf("Failed to load previous route snapshot: %v", err)
+		}
+		if len(previous) == 0 {
+			log.Printf("No previous route snapshot, storing %d routes as baseline", len(current))
+			cn.storeRoutes(ctx, current, routeIDs(current))
+			cn.previous = current
+			return
+		}
+		cn.previous = previous
+	}
+
+	if len(data.FailedCities) > 0 {
+		current = keepFailedCities(cn.previous, current, data.FailedCities)
+	}
+
+	diff := garbage.DiffRoutes(cn.previous, current)
+	cn.previous = current
+	if diff.Empty() {
+		return
+	}
+
+	log.Printf("Dataset changed: %d stops added, %d removed, %d rescheduled",
+		len(diff.Added), len(diff.Removed), len(diff.Changed))
+
+	cn.storeRoutes(ctx, current, diff.RouteIDs())
+
+	if err := cn.notifyAffectedUsers(ctx, diff); err != nil {
+		log.Printf("Error notifying users about dataset changes: %v", err)
+	}
+}
+
+// keepFailedCities replaces the routes of cities whose provider failed with
+// their previous version, so a failed download is not reported as every stop of
+// the city being removed. Snapshot routes stored without a city are kept too
+// when they are missing from current.
+func keepFailedCities(previous, current map[string]*garbage.Route, failedCities []string) map[string]*garbage.Route {
+	failed := make(map[string]bool, len(failedCities))
+	for _, city := range failedCities {
+		failed[city] = true
+	}
+
+	kept := make(map[string]*garbage.Route, len(current))
+	for id, route := range current {
+		if !failed[route.City] {
+			kept[id] = route
+		}
+	}
+	for id, route := range previous {
+		_, inCurrent := current[id]
+		if failed[route.City] || (route.City == "" && !inCurrent) {
+			kept[id] = route
+		}
+	}
+	return kept
+}
+
+func (cn *ChangeNotifier) loadSnapshot(ctx context.Context) (map[string]*garbage.Route, error) {
+	docs, err := cn.store.GetAllRoutes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make(map[string]*garbage.Route, len(docs))
+	var legacy []string
+	for _, doc := range docs {
+		route := garbage.RouteFromSnapshotData(doc.Data)
+		if route.ID == "" {
+			continue
+		}
+		routes[route.ID] = route
+		if doc.ID != routeDocID(route.ID) {
+			legacy = append(legacy, doc.ID)
+		}
+	}
+
+	// Move routes stored under keys without a city to their current key
+	if len(legacy) > 0 {
+		log.Printf("Re-keying %d route snapshots stored without a city", len(legacy))
+		cn.storeRoutes(ctx, routes, routeIDs(routes))
+		for _, id := range legacy {
+			if err := cn.store.DeleteRouteData(ctx, id); err != nil {
+				log.Printf("Failed to delete legacy route snapshot %s: %v", id, err)
+			}
+		}
+	}
+	return routes, nil
+}
+
+// storeRoutes writes the given routes of current to the snapshot, deleting the
+// ones that no longer exist.
+func (cn *ChangeNotifier) storeRoutes(ctx context.Context, current map[string]*garbage.Route, ids []string) {
+	for _, id := range ids {
+		var err error
+		if route, ok := current[id]; ok {
+			err = cn.store.StoreRouteData(ctx, routeDocID(id), garbage.RouteSnapshotData(route))
+		} else {
+			err = cn.store.DeleteRouteData(ctx, routeDocID(id))
+		}
+		if err != nil {
+			log.Printf("Failed to update route snapshot %s: %v", id, err)
+		}
+	}
+}
+
+func (cn *ChangeNotifier) notifyAffectedUsers(ctx context.Context, diff *garbage.SnapshotDiff) error {
+	affected := make(map[string][]string)
+	addLine := func(userID, line string) {
+		for _, existing := range affected[userID] {
+			if existing == line {
+				return
+			}
+		}
+		affected[userID] = append(affected[userID], line)
+	}
+
+	reminders, err := cn.store.GetActiveReminders(ctx, utils.NowInTaiwan())
+	if err != nil {
+		return fmt.Errorf("failed to get active reminders: %w", err)
+	}
+	for _, reminder := range reminders {
+		for _, changes := range [][]garbage.StopChange{diff.Removed, diff.Changed} {
+			for _, change := range changes {
+				if change.Location != reminder.StopName {
+					continue
+				}
+				if !reminderOnRoute(reminder.RouteID, change) {
+					continue
+				}
+				addLine(reminder.UserID, "[提醒] "+describeChange(change))
+			}
+		}
+	}
+
+	users, err := cn.store.GetAllUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get users: %w", err)
+	}
+	for _, user := range users {
+		for _, favorite := range user.Favorites {
+			for _, changes := range [][]garbage.StopChange{diff.Removed, diff.Changed, diff.Added} {
+				for _, change := range changes {
+					if geo.CalculateDistance(favorite.Lat, favorite.Lng, change.Lat, change.Lng) > favoriteImpactRadius {
+						continue
+					}
+					addLine(user.ID, fmt.Sprintf("[%s] %s", favorite.Name, describeChange(change)))
+				}
+			}
+		}
+	}
+
+	userIDs := make([]string, 0, len(affected))
+	for userID := range affected {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+
+	for _, userID := range userIDs {
+		if err := cn.sendChangeNotice(userID, affected[userID]); err != nil {
+			log.Printf("Failed to send change notice to user %s: %v", userID, err)
+		}
+	}
+
+	log.Printf("Sent dataset change notices to %d users", len(userIDs))
+	return nil
+}
+
+func (cn *ChangeNotifier) sendChangeNotice(userID string, lines []string) error {
+	var sb strings.Builder
+	sb.WriteString("📢 垃圾車資料異動\n\n您收藏地點附近或設定提醒的站點有變更：\n")
+	for i, line := range lines {
+		if i == maxChangeLines {
+			sb.WriteString(fmt.Sprintf("⋯ 還有 %d 項變更\n", len(lines)-maxChangeLines))
+			break
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n請重新查詢確認最新時間。")
+
+	req := &messaging_api.PushMessageRequest{
+		To:       userID,
+		Messages: []messaging_api.MessageInterface{&messaging_api.TextMessage{Text: sb.String()}},
+	}
+
+	_, err := cn.messagingAPI.PushMessage(req, "")
+	return err
+}
+
+func describeChange(change garbage.StopChange) string {
+	switch {
+	case change.OldTime == "":
+		return fmt.Sprintf("%s（%s）新增 %s 停靠", change.Location, change.RouteName, garbage.FormatClock(change.NewTime))
+	case change.NewTime == "":
+		return fmt.Sprintf("%s（%s）已取消停靠", change.Location, change.RouteName)
+	default:
+		return fmt.Sprintf("%s（%s）%s → %s", change.Location, change.RouteName,
+			garbage.FormatClock(change.OldTime), garbage.FormatClock(change.NewTime))
+	}
+}
+
+// reminderOnRoute reports whether a reminder's route is the changed route.
+// Reminders saved before RouteKey carried the city hold a TripKey or a bare
+// vehicle number.
+func reminderOnRoute(routeID string, change garbage.StopChange) bool {
+	return change.RouteID == routeID ||
+		change.VehicleNumber == routeID ||
+		strings.HasSuffix(change.RouteID, ":"+routeID)
+}
+
+// routeDocID makes a route key safe to use as a Firestore document ID.
+func routeDocID(routeID string) string {
+	return strings.ReplaceAll(routeID, "/", "_")
+}
+
+func routeIDs(routes map[string]*garbage.Route) []string {
+	ids := make([]string, 0, len(routes))
+	for id := range routes {
+		ids = append(ids, id)
+	}
+	return ids
+}
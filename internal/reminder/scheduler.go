@@ -8,23 +8,26 @@ import (
 
 	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
 
+	"linebot-garbage-helper/internal/garbage"
 	"linebot-garbage-helper/internal/store"
 	"linebot-garbage-helper/internal/utils"
 )
 
 type Scheduler struct {
-	store        *store.FirestoreClient
-	messagingAPI *messaging_api.MessagingApiAPI
+	store          *store.FirestoreClient
+	messagingAPI   *messaging_api.MessagingApiAPI
+	garbageAdapter *garbage.GarbageAdapter
 }
 
 type ReminderService struct {
 	scheduler *Scheduler
 }
 
-func NewScheduler(store *store.FirestoreClient, messagingAPI *messaging_api.MessagingApiAPI) *Scheduler {
+func NewScheduler(store *store.FirestoreClient, messagingAPI *messaging_api.MessagingApiAPI, garbageAdapter *garbage.GarbageAdapter) *Scheduler {
 	return &Scheduler{
-		store:        store,
-		messagingAPI: messagingAPI,
+		store:          store,
+		messagingAPI:   messagingAPI,
+		garbageAdapter: garbageAdapter,
 	}
 }
 
@@ -97,6 +100,14 @@ func (s *Scheduler) processReminder(ctx context.Context, reminder *store.Reminde
 		return nil
 	}
 
+	if special, ok := s.suspendedOn(ctx, reminder); ok {
+		log.Printf("Reminder %s: Collection suspended on %s (%s), skipping notification", reminder.ID, special.Date, special.Note)
+		if err := s.sendSuspensionNotice(reminder, special); err != nil {
+			log.Printf("Failed to send suspension notice for reminder %s: %v", reminder.ID, err)
+		}
+		return s.store.UpdateReminderStatus(ctx, reminder.ID, "skipped")
+	}
+
 	log.Printf("Reminder %s: Sending notification to user %s for stop %s", reminder.ID, reminder.UserID, reminder.StopName)
 	err := s.sendReminderNotification(ctx, reminder)
 	if err != nil {
@@ -138,6 +149,46 @@ func (s *Scheduler) sendReminderNotification(ctx context.Context, reminder *stor
 	return err
 }
 
+// suspendedOn reports whether the holiday calendar suspends collection for the
+// reminder's stop on its ETA date.
+func (s *Scheduler) suspendedOn(ctx context.Context, reminder *store.Reminder) (garbage.SpecialDay, bool) {
+	data, err := s.garbageAdapter.GetGarbageData(ctx)
+	if err != nil {
+		log.Printf("Cannot check holiday calendar for reminder %s: %v", reminder.ID, err)
+		return garbage.SpecialDay{}, false
+	}
+
+	point, err := s.garbageAdapter.CollectionPointForStop(data, reminder.RouteID, reminder.StopName)
+	if err != nil {
+		log.Printf("Cannot check holiday calendar for reminder %s: %v", reminder.ID, err)
+		return garbage.SpecialDay{}, false
+	}
+
+	if s.garbageAdapter.Calendar().IsServiceDay(point, reminder.ETA) {
+		return garbage.SpecialDay{}, false
+	}
+
+	special, ok := s.garbageAdapter.Calendar().SpecialDayFor(point, reminder.ETA)
+	if !ok {
+		// Not a service day by the weekday rules either
+		special = garbage.SpecialDay{Date: utils.ToTaiwan(reminder.ETA).Format("2006-01-02"), Type: garbage.SpecialDaySuspended, Note: "非收運日"}
+	}
+	return special, true
+}
+
+func (s *Scheduler) sendSuspensionNotice(reminder *store.Reminder, special garbage.SpecialDay) error {
+	message := fmt.Sprintf("📅 垃圾車停收通知\n\n%s（%s）垃圾車停收，%s 不會有垃圾車，本次提醒已取消。",
+		special.Date, special.Note, reminder.StopName)
+
+	req := &messaging_api.PushMessageRequest{
+		To:       reminder.UserID,
+		Messages: []messaging_api.MessageInterface{&messaging_api.TextMessage{Text: message}},
+	}
+
+	_, err := s.messagingAPI.PushMessage(req, "")
+	return err
+}
+
 func (s *Scheduler) CleanupExpiredReminders(ctx context.Context) error {
 	cutoffTime := time.Now().Add(-24 * time.Hour)
 	
@@ -2,11 +2,16 @@ package store
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	"linebot-garbage-helper/internal/geo"
 )
 
 type FirestoreClient struct {
@@ -18,6 +23,13 @@ type User struct {
 	Favorites []Favorite `firestore:"favorites"`
 	CreatedAt time.Time  `firestore:"createdAt"`
 	UpdatedAt time.Time  `firestore:"updatedAt"`
+
+	// LastLocation is the most recent location the user shared
+	LastLocation   *Favorite `firestore:"lastLocation,omitempty"`
+	LastLocationAt time.Time `firestore:"lastLocationAt"`
+
+	// MapProvider is the map app navigation links open in; empty means Google Maps
+	MapProvider string `firestore:"mapProvider,omitempty"`
 }
 
 type Favorite struct {
@@ -45,6 +57,14 @@ type Route struct {
 	UpdatedAt time.Time              `firestore:"updatedAt"`
 }
 
+type GeocodeCacheEntry struct {
+	Key       string    `firestore:"key"`
+	Lat       float64   `firestore:"lat"`
+	Lng       float64   `firestore:"lng"`
+	Address   string    `firestore:"address"`
+	ExpiresAt time.Time `firestore:"expiresAt"`
+}
+
 func NewFirestoreClient(ctx context.Context, projectID string) (*FirestoreClient, error) {
 	client, err := firestore.NewClient(ctx, projectID)
 	if err != nil {
@@ -71,6 +91,25 @@ func (fc *FirestoreClient) GetUser(ctx context.Context, userID string) (*User, e
 	return &user, nil
 }
 
+func (fc *FirestoreClient) GetAllUsers(ctx context.Context) ([]*User, error) {
+	docs, err := fc.client.Collection("users").Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var users []*User
+	for _, doc := range docs {
+		var user User
+		if err := doc.DataTo(&user); err != nil {
+			continue
+		}
+		user.ID = doc.Ref.ID
+		users = append(users, &user)
+	}
+
+	return users, nil
+}
+
 func (fc *FirestoreClient) UpsertUser(ctx context.Context, user *User) error {
 	user.UpdatedAt = time.Now()
 	if user.CreatedAt.IsZero() {
@@ -81,6 +120,26 @@ func (fc *FirestoreClient) UpsertUser(ctx context.Context, user *User) error {
 	return err
 }
 
+func (fc *FirestoreClient) UpdateLastLocation(ctx context.Context, userID string, location Favorite) error {
+	now := time.Now()
+	_, err := fc.client.Collection("users").Doc(userID).Set(ctx, map[string]interface{}{
+		"id":             userID,
+		"lastLocation":   location,
+		"lastLocationAt": now,
+		"updatedAt":      now,
+	}, firestore.MergeAll)
+	return err
+}
+
+func (fc *FirestoreClient) UpdateMapProvider(ctx context.Context, userID, provider string) error {
+	_, err := fc.client.Collection("users").Doc(userID).Set(ctx, map[string]interface{}{
+		"id":          userID,
+		"mapProvider": provider,
+		"updatedAt":   time.Now(),
+	}, firestore.MergeAll)
+	return err
+}
+
 func (fc *FirestoreClient) AddFavorite(ctx context.Context, userID string, favorite Favorite) error {
 	userRef := fc.client.Collection("users").Doc(userID)
 	
@@ -161,6 +220,33 @@ func (fc *FirestoreClient) GetActiveReminders(ctx context.Context, targetTime ti
 	return reminders, nil
 }
 
+// GetUserActiveReminders returns the user's active reminders, soonest first.
+func (fc *FirestoreClient) GetUserActiveReminders(ctx context.Context, userID string) ([]*Reminder, error) {
+	// Use single field query to avoid index requirement
+	query := fc.client.Collection("reminders").
+		Where("userId", "==", userID)
+
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []*Reminder
+	for _, doc := range docs {
+		var reminder Reminder
+		if err := doc.DataTo(&reminder); err != nil || reminder.Status != "active" {
+			continue
+		}
+		reminder.ID = doc.Ref.ID
+		reminders = append(reminders, &reminder)
+	}
+
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].ETA.Before(reminders[j].ETA)
+	})
+	return reminders, nil
+}
+
 func (fc *FirestoreClient) UpdateReminderStatus(ctx context.Context, reminderID, status string) error {
 	_, err := fc.client.Collection("reminders").Doc(reminderID).Update(ctx, []firestore.Update{
 		{Path: "status", Value: status},
@@ -180,6 +266,11 @@ func (fc *FirestoreClient) StoreRouteData(ctx context.Context, routeID string, d
 	return err
 }
 
+func (fc *FirestoreClient) DeleteRouteData(ctx context.Context, routeID string) error {
+	_, err := fc.client.Collection("routes").Doc(routeID).Delete(ctx)
+	return err
+}
+
 func (fc *FirestoreClient) GetRouteData(ctx context.Context, routeID string) (*Route, error) {
 	doc, err := fc.client.Collection("routes").Doc(routeID).Get(ctx)
 	if err != nil {
@@ -211,4 +302,47 @@ func (fc *FirestoreClient) GetAllRoutes(ctx context.Context) ([]*Route, error) {
 	}
 	
 	return routes, nil
-}
\ No newline at end of file
+}
+
+// GetGeocode implements geo.CacheStore. It returns nil when the key is not stored.
+func (fc *FirestoreClient) GetGeocode(ctx context.Context, key string) (*geo.Location, time.Time, error) {
+	doc, err := fc.client.Collection("geocode_cache").Doc(geocodeDocID(key)).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var entry GeocodeCacheEntry
+	if err := doc.DataTo(&entry); err != nil {
+		return nil, time.Time{}, err
+	}
+	// 雜湊碰撞時視為沒有快取
+	if entry.Key != key {
+		return nil, time.Time{}, nil
+	}
+
+	return &geo.Location{Lat: entry.Lat, Lng: entry.Lng, Address: entry.Address}, entry.ExpiresAt, nil
+}
+
+// SaveGeocode implements geo.CacheStore.
+func (fc *FirestoreClient) SaveGeocode(ctx context.Context, key string, location *geo.Location, expiresAt time.Time) error {
+	entry := &GeocodeCacheEntry{
+		Key:       key,
+		Lat:       location.Lat,
+		Lng:       location.Lng,
+		Address:   location.Address,
+		ExpiresAt: expiresAt,
+	}
+
+	_, err := fc.client.Collection("geocode_cache").Doc(geocodeDocID(key)).Set(ctx, entry)
+	return err
+}
+
+// geocodeDocID hashes the cache key, since addresses may contain characters
+// that are not allowed in document IDs.
+func geocodeDocID(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"linebot-garbage-helper/internal/geo"
+)
+
+// 地址解析應處理全形數字、台／臺、國字段號，並拆出路、段、巷、弄、號、樓
+func main() {
+	fmt.Println("地址解析測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	cases := []struct {
+		text string
+		want geo.Address
+	}{
+		{"臺北市大安區新生南路２段30巷5號3樓", geo.Address{City: "台北市", District: "大安區", Road: "新生南路", Section: 2, Lane: "30", Number: "5", Floor: "3"}},
+		{"100台北市中正區重慶南路一段122號", geo.Address{City: "台北市", District: "中正區", Road: "重慶南路", Section: 1, Number: "122"}},
+		{"新北市板橋區縣民大道二段7號", geo.Address{City: "新北市", District: "板橋區", Road: "縣民大道", Section: 2, Number: "7"}},
+		{"台北大安區龍安里", geo.Address{City: "台北市", District: "大安區", Village: "龍安里"}},
+		{"高雄市左營區博愛二路777號", geo.Address{City: "高雄市", District: "左營區", Road: "博愛二路", Number: "777"}},
+		{"仁愛路四段27巷口", geo.Address{Road: "仁愛路", Section: 4, Lane: "27"}},
+		{"100巷5號", geo.Address{Lane: "100", Number: "5"}},
+		{"和平東路一段100-1號B1", geo.Address{Road: "和平東路", Section: 1, Number: "100之1", Floor: "B1"}},
+		{"桃園市桃園區中山路300號十二樓", geo.Address{City: "桃園市", District: "桃園區", Road: "中山路", Number: "300", Floor: "12"}},
+		{"我住在台北市信義區", geo.Address{City: "台北市", District: "信義區", Rest: "我住在"}},
+		{"全家 復興店", geo.Address{Rest: "全家復興店"}},
+	}
+
+	failed := 0
+	for _, c := range cases {
+		got := geo.ParseAddress(c.text)
+		if got != c.want {
+			fmt.Printf("❌ %s\n   得到 %#v\n   預期 %#v\n", c.text, got, c.want)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %-28s → %s\n", c.text, got.String()+got.Rest)
+	}
+
+	// 同一個地址的不同寫法應有相同的 Key，用於收藏去重
+	same := []string{"臺北市大安區新生南路二段30號", "台北市大安區新生南路2段３０號", "台北市 大安區 新生南路二段30號5樓"}
+	key := geo.ParseAddress(same[0]).Key()
+	for _, text := range same[1:] {
+		if geo.ParseAddress(text).Key() != key {
+			fmt.Printf("❌ %s 的 Key 應為 %s\n", text, key)
+			failed++
+		}
+	}
+	if geo.ParseAddress("台北市大安區新生南路二段32號").Key() == key {
+		fmt.Println("❌ 不同門牌不應有相同的 Key")
+		failed++
+	}
+	fmt.Printf("✅ 同一地址的 %d 種寫法 Key 相同：%s\n", len(same), key)
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("✨ 所有地址都正確解析！")
+}
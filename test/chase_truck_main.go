@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// 垃圾車剛離開新生南路時，找出同一車次後面還走得到的站點
+func main() {
+	fmt.Println("追垃圾車測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	adapter := garbage.NewGarbageAdapter(garbage.NewTaipeiProvider("internal/garbage/testdata/taipei.json"))
+	data, err := adapter.GetGarbageData(context.Background())
+	if err != nil {
+		fmt.Printf("❌ 讀取失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := 0
+	check := func(name string, now time.Time, want []string) {
+		stops, err := adapter.ChaseTruck(data, "KES-1021_第1車", "臺北市大安區新生南路二段30號", 25.0300, 121.5330, now)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", name, err)
+			failed++
+			return
+		}
+
+		var got []string
+		for _, stop := range stops {
+			got = append(got, stop.Stop.Name)
+			fmt.Printf("   %s %s－%s 步行 %.1f 分鐘，提早 %.1f 分鐘\n", stop.Stop.Name,
+				stop.Arrival.Format("15:04"), stop.Departure.Format("15:04"), stop.WalkTime.Minutes(), stop.Spare.Minutes())
+		}
+
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			fmt.Printf("❌ %s: %v，預期 %v\n", name, got, want)
+			failed++
+			return
+		}
+		fmt.Printf("✅ %s: %d 個站點\n", name, len(got))
+	}
+
+	tz := utils.GetTaiwanTimezone()
+	// 週一 19:07，垃圾車 19:05 離開新生南路
+	check("剛錯過", time.Date(2026, 3, 2, 19, 7, 0, 0, tz), []string{"臺北市大安區和平東路一段100號", "臺北市大安區麗水街13巷口"})
+	// 週一 19:15，和平東路也來不及了
+	check("錯過更久", time.Date(2026, 3, 2, 19, 15, 0, 0, tz), []string{"臺北市大安區麗水街13巷口"})
+	// 週一 19:30，全部都來不及
+	check("全部錯過", time.Date(2026, 3, 2, 19, 30, 0, 0, tz), nil)
+
+	// 深夜車次 23:50 剛離開，過午夜的站點要算在隔天
+	var night []garbage.CollectionPoint
+	for i, s := range []struct{ location, arrival, departure, lat, lng string }{
+		{"臺北市大安區新生南路二段30號", "2350", "2355", "25.030000", "121.533000"},
+		{"臺北市大安區和平東路一段100號", "0010", "0015", "25.026400", "121.528300"},
+		{"臺北市大安區麗水街13巷口", "0020", "0025", "25.027900", "121.529100"},
+	} {
+		night = append(night, garbage.CollectionPoint{
+			ID: fmt.Sprintf("taipei-%d", 800+i), City: "台北市", VehicleNumber: "KES-1300", VehicleTrip: "第1車",
+			Route: "大安區深夜路線", ArrivalTime: s.arrival, DepartureTime: s.departure, Location: s.location,
+			Latitude: s.lat, Longitude: s.lng,
+		})
+	}
+	nightData := &garbage.GarbageData{Result: garbage.GarbageResult{Count: len(night), Results: night}}
+	// 週一 23:57 錯過，週二 00:10 的站點還來得及
+	now := time.Date(2026, 3, 2, 23, 57, 0, 0, tz)
+	stops, err := adapter.ChaseTruck(nightData, garbage.RouteKey("台北市", "KES-1300", "第1車"), night[0].Location, 25.0300, 121.5330, now)
+	switch {
+	case err != nil:
+		fmt.Printf("❌ 跨午夜: %v\n", err)
+		failed++
+	case len(stops) == 0:
+		fmt.Printf("❌ 跨午夜: 找不到過午夜的站點\n")
+		failed++
+	default:
+		ok := true
+		for _, stop := range stops {
+			fmt.Printf("   %s %s\n", stop.Stop.Name, stop.Arrival.Format("01/02 15:04"))
+			if stop.Arrival.Day() != 3 {
+				ok = false
+			}
+		}
+		if !ok {
+			fmt.Printf("❌ 跨午夜: 過午夜的站點應在 03/03\n")
+			failed++
+		} else {
+			fmt.Printf("✅ 跨午夜: %d 個站點在隔天\n", len(stops))
+		}
+	}
+
+	// 週二晚上出發，過午夜的站點是週三，但收運日依車次出發的週二判斷
+	tuesday := time.Date(2026, 3, 3, 23, 57, 0, 0, tz)
+	stops, err = adapter.ChaseTruck(nightData, garbage.RouteKey("台北市", "KES-1300", "第1車"), night[0].Location, 25.0300, 121.5330, tuesday)
+	if err != nil || len(stops) == 0 {
+		fmt.Printf("❌ 週二夜車: %d 個站點 (%v)，過午夜的站點不應因週三停收而略過\n", len(stops), err)
+		failed++
+	} else {
+		fmt.Printf("✅ 週二夜車: %d 個站點在週三凌晨\n", len(stops))
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("✨ 追車站點計算正確！")
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// 驗證收運日曆：週三、週日不收一般垃圾，下一班會跳到下一個收運日
+func main() {
+	fmt.Println("收運日曆測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	tz := utils.GetTaiwanTimezone()
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, tz)
+	}
+
+	calendar := garbage.NewCollectionCalendar()
+	point := &garbage.CollectionPoint{
+		City:          "台北市",
+		VehicleNumber: "KES-1021",
+		VehicleTrip:   "第1車",
+		ArrivalTime:   "1900",
+		DepartureTime: "1905",
+		Location:      "臺北市大安區新生南路二段30號",
+	}
+
+	failed := 0
+	check := func(name string, got, want time.Time, err error) {
+		if err != nil || !got.Equal(want) {
+			fmt.Printf("❌ %-24s → %s (%v)，預期 %s\n", name, got.Format("01/02 Mon 15:04"), err, want.Format("01/02 Mon 15:04"))
+			failed++
+			return
+		}
+		fmt.Printf("✅ %-24s → %s\n", name, got.Format("01/02 Mon 15:04"))
+	}
+
+	// 2026-10-13 是星期二
+	arrival, err := calendar.NextArrival(point, at(13, 18, 0))
+	check("週二抵達前", arrival, at(13, 19, 0), err)
+
+	arrival, err = calendar.NextArrival(point, at(13, 20, 0))
+	check("週二錯過，跳過週三", arrival, at(15, 19, 0), err)
+
+	arrival, err = calendar.NextArrival(point, at(14, 6, 0))
+	check("週三當天", arrival, at(15, 19, 0), err)
+
+	arrival, err = calendar.NextArrival(point, at(17, 20, 0))
+	check("週六錯過，跳過週日", arrival, at(19, 19, 0), err)
+
+	arrival, err = calendar.NextArrival(point, at(18, 12, 0))
+	check("週日當天", arrival, at(19, 19, 0), err)
+
+	for _, c := range []struct {
+		day  time.Time
+		want bool
+	}{
+		{at(13, 0, 0), true},
+		{at(14, 0, 0), false},
+		{at(18, 0, 0), false},
+		{at(19, 0, 0), true},
+	} {
+		if got := calendar.IsServiceDay(point, c.day); got != c.want {
+			fmt.Printf("❌ %s 是否收運 → %v，預期 %v\n", c.day.Format("01/02 Mon"), got, c.want)
+			failed++
+		}
+	}
+
+	// 週二晚上出發的車次，過午夜的站點依週二判斷收運日，不會因為週三停收而跳過
+	night := []garbage.CollectionPoint{
+		{City: "台北市", VehicleNumber: "KES-1300", VehicleTrip: "第1車", ArrivalTime: "2350", DepartureTime: "2355",
+			Location: "臺北市大安區新生南路二段30號", Latitude: "25.030000", Longitude: "121.533000"},
+		{City: "台北市", VehicleNumber: "KES-1300", VehicleTrip: "第1車", ArrivalTime: "0010", DepartureTime: "0015",
+			Location: "臺北市大安區和平東路一段100號", Latitude: "25.026400", Longitude: "121.528300"},
+	}
+	garbage.BuildRoutes(night)
+	arrival, err = calendar.NextArrival(&night[1], at(13, 23, 0))
+	check("週二夜車過午夜的站點", arrival, at(14, 0, 10), err)
+	arrival, err = calendar.NextArrival(&night[1], at(14, 1, 0))
+	check("週三夜車停收", arrival, at(16, 0, 10), err)
+
+	// 路線規則優先於縣市規則，站點自己的收運日又優先於路線規則
+	routeCalendar := garbage.NewCollectionCalendar()
+	routeCalendar.SetRouteDays(garbage.RouteKey(point.City, point.VehicleNumber, point.VehicleTrip), garbage.NewWeekdays(time.Wednesday))
+	arrival, err = routeCalendar.NextArrival(point, at(13, 20, 0))
+	check("路線只收週三", arrival, at(14, 19, 0), err)
+
+	ownDays := *point
+	ownDays.ServiceDays = garbage.NewWeekdays(time.Sunday)
+	arrival, err = routeCalendar.NextArrival(&ownDays, at(13, 20, 0))
+	check("站點只收週日", arrival, at(18, 19, 0), err)
+
+	if days := calendar.ServiceDays(point).String(); days != "一二四五六" {
+		fmt.Printf("❌ 台北市收運日 → %s，預期 一二四五六\n", days)
+		failed++
+	}
+
+	// 透過 adapter 查詢範例資料的站點，也要依日曆計算
+	adapter := garbage.NewGarbageAdapter(garbage.NewTaipeiProvider("internal/garbage/testdata/taipei.json"))
+	data, err := adapter.GetGarbageData(context.Background())
+	if err != nil {
+		fmt.Printf("❌ 讀取失敗: %v\n", err)
+		os.Exit(1)
+	}
+	arrival, err = adapter.NextArrivalForStop(data, "KES-1021_第1車", point.Location, at(17, 20, 0))
+	check("範例資料週六錯過", arrival, at(19, 19, 0), err)
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		fmt.Printf("❌ %d 項失敗\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("✨ 收運日曆測試全部通過！")
+}
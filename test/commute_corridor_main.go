@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/geo"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// 以固定出發時間驗證沿途走廊搜尋：只保留走廊內、經過時剛好有垃圾車的站點
+func main() {
+	fmt.Println("順路倒垃圾測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	failed := 0
+
+	// 點到線段的距離：線段中點正北約 111 公尺
+	path := []geo.Point{{Lat: 25.0300, Lng: 121.5200}, {Lat: 25.0300, Lng: 121.5300}}
+	distance, along := geo.DistanceToPath(25.0310, 121.5250, path)
+	if distance < 105 || distance > 117 || along < 480 || along > 530 {
+		fmt.Printf("❌ DistanceToPath = %.0f, %.0f\n", distance, along)
+		failed++
+	} else {
+		fmt.Printf("✅ 距離線段 %.0f 公尺，沿線 %.0f 公尺\n", distance, along)
+	}
+
+	adapter := garbage.NewGarbageAdapter(garbage.NewTaipeiProvider("internal/garbage/testdata/taipei.json"))
+	data, err := adapter.GetGarbageData(context.Background())
+	if err != nil {
+		fmt.Printf("❌ 讀取失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 週一 18:55 從新生南路出發走到麗水街
+	depart := time.Date(2026, 3, 2, 18, 55, 0, 0, utils.GetTaiwanTimezone())
+	stops, err := adapter.FindStopsAlongPath(data, garbage.CorridorQuery{
+		Path:   []geo.Point{{Lat: 25.0301, Lng: 121.5331}, {Lat: 25.0286, Lng: 121.5279}},
+		Width:  300,
+		Depart: depart,
+	})
+	if err != nil {
+		fmt.Printf("❌ 查詢失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	found := make(map[string]bool)
+	for _, stop := range stops {
+		found[stop.Stop.Name] = true
+		fmt.Printf("   %s：%s 經過，垃圾車 %s（%s，離路線 %.0f 公尺）\n",
+			stop.Stop.Name, stop.PassAt.Format("15:04"), stop.ETA.Format("15:04"), stop.Status, stop.Distance)
+	}
+
+	if !found["臺北市大安區新生南路二段30號"] {
+		fmt.Println("❌ 應包含出發點旁 19:00 到站的新生南路")
+		failed++
+	} else {
+		fmt.Println("✅ 包含新生南路（19:00 到站）")
+	}
+	if found["臺北市中正區仁愛路二段27巷口"] {
+		fmt.Println("❌ 仁愛路在走廊外，不應出現")
+		failed++
+	} else {
+		fmt.Println("✅ 排除走廊外的仁愛路")
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Printf("✨ 沿途找到 %d 個站點\n", len(stops))
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"linebot-garbage-helper/internal/garbage"
+)
+
+// 分享的位置不在任何縣市資料範圍內時，不應把幾百公里外的站點當作一般結果
+func main() {
+	fmt.Println("服務範圍測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	adapter := garbage.NewGarbageAdapter(
+		garbage.NewTaipeiProvider("internal/garbage/testdata/taipei.json"),
+		garbage.NewKaohsiungProvider("internal/garbage/testdata/kaohsiung.json"),
+	)
+	data, err := adapter.GetGarbageData(context.Background())
+	if err != nil {
+		fmt.Printf("❌ 讀取失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	coverage := data.Coverage()
+	fmt.Printf("支援縣市：%s\n\n", strings.Join(coverage.Cities(), "、"))
+
+	cases := []struct {
+		name     string
+		lat, lng float64
+		want     string
+	}{
+		{"大安區新生南路", 25.0300, 121.5330, "台北市"},
+		{"資料點外約 1 公里", 25.0380, 121.5330, "台北市"},
+		{"左營區博愛二路", 22.6711, 120.3028, "高雄市"},
+		{"台南市政府", 22.9920, 120.1850, ""},
+		{"花蓮火車站", 23.9930, 121.6010, ""},
+		{"台北 101（無範例資料）", 25.0339, 121.5645, ""},
+	}
+
+	failed := 0
+	for _, c := range cases {
+		got := strings.Join(coverage.CitiesAt(c.lat, c.lng), "、")
+		if got != c.want || coverage.Covers(c.lat, c.lng) != (c.want != "") {
+			fmt.Printf("❌ %-20s → %q，預期 %q\n", c.name, got, c.want)
+			failed++
+			continue
+		}
+		if got == "" {
+			fmt.Printf("✅ %-20s → 不在服務範圍\n", c.name)
+		} else {
+			fmt.Printf("✅ %-20s → %s\n", c.name, got)
+		}
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("✨ 服務範圍判斷正確！")
+}
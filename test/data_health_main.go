@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/garbage"
+)
+
+// 使用含錯誤資料的範例檔驗證資料品質檢查的分類結果
+func main() {
+	fmt.Println("資料品質檢查測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	provider := garbage.NewTaipeiProvider("internal/garbage/testdata/taipei_invalid.json")
+	points, err := provider.Fetch(context.Background(), &http.Client{Timeout: 10 * time.Second})
+	if err != nil {
+		fmt.Printf("❌ 讀取失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := garbage.ValidateDataset(points)
+
+	expected := map[garbage.IssueKind]int{
+		garbage.IssueSwappedLatLng:          1,
+		garbage.IssueOutsideTaiwan:          1,
+		garbage.IssueInvalidArrivalTime:     1,
+		garbage.IssueInvalidCoordinates:     1,
+		garbage.IssueDepartureBeforeArrival: 1,
+		garbage.IssueDuplicateRow:           1,
+	}
+
+	failed := 0
+	for kind, want := range expected {
+		got := report.Counts[kind]
+		if got != want {
+			fmt.Printf("❌ %s: %d 筆，預期 %d 筆\n", kind, got, want)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %s: %d 筆（%s）\n", kind, got, report.Samples[kind][0].Location)
+	}
+
+	// 2355 抵達、0005 離開是跨午夜，不算離開早於抵達
+	for _, issue := range report.Samples[garbage.IssueDepartureBeforeArrival] {
+		if issue.Location == "跨午夜站點" {
+			fmt.Println("❌ 跨午夜的站點不應標記為離開早於抵達")
+			failed++
+		}
+	}
+
+	if report.FlaggedRows != 5 {
+		fmt.Printf("❌ 有問題的資料 %d 筆，預期 5 筆\n", report.FlaggedRows)
+		failed++
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Printf("✨ 共 %d 筆資料，%d 筆有問題，分類正確！\n", report.TotalPoints, report.FlaggedRows)
+}
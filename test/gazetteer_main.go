@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/garbage"
+)
+
+// 行政區與里名查詢應直接由資料集解析，含有路名的地址則交給地理編碼
+func main() {
+	fmt.Println("行政區／里名查詢測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	adapter := garbage.NewGarbageAdapter(garbage.NewTaipeiProvider("internal/garbage/testdata/taipei.json"))
+	data, err := adapter.GetGarbageData(context.Background())
+	if err != nil {
+		fmt.Printf("❌ 讀取失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	gazetteer := data.Gazetteer()
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"大安區 龍安里", []string{"台北市/大安區/龍安里"}},
+		{"臺北市大安區龍安里", []string{"台北市/大安區/龍安里"}},
+		{"龍安", []string{"台北市/大安區/龍安里"}},
+		{"錦安里附近", []string{"台北市/大安區/錦安里"}},
+		{"台北 大安", []string{"台北市/大安區"}},
+		{"中正區", []string{"台北市/中正區"}},
+		{"台北市", nil},
+		{"台北市大安區新生南路二段", nil},
+		{"信義區", nil},
+	}
+
+	failed := 0
+	for _, c := range cases {
+		var got []string
+		for _, area := range gazetteer.Lookup(c.query) {
+			got = append(got, area.ID)
+		}
+
+		if strings.Join(got, ",") != strings.Join(c.want, ",") {
+			fmt.Printf("❌ %-24s → %v，預期 %v\n", c.query, got, c.want)
+			failed++
+			continue
+		}
+		if len(got) == 0 {
+			fmt.Printf("✅ %-24s → 交給地理編碼\n", c.query)
+		} else {
+			fmt.Printf("✅ %-24s → %s\n", c.query, strings.Join(got, ", "))
+		}
+	}
+
+	area, ok := gazetteer.Area("台北市/大安區/龍安里")
+	if !ok {
+		fmt.Println("❌ 找不到龍安里")
+		os.Exit(1)
+	}
+
+	stops, err := adapter.FindStopsInArea(data, area, garbage.TimeWindow{}, time.Now())
+	if err != nil {
+		fmt.Printf("❌ 查詢失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n📍 %s（中心 %.4f, %.4f）\n", area.Name(), area.Lat, area.Lng)
+	for _, stop := range stops {
+		fmt.Printf("   %s（%d 個車次）下一班 %s\n", stop.Stop.Name, stop.Trips, stop.ETA.Format("01/02 15:04"))
+	}
+	if len(stops) != 2 {
+		fmt.Printf("❌ 龍安里應有 2 個站點，實際 %d 個\n", len(stops))
+		failed++
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("✨ 所有查詢都符合預期！")
+}
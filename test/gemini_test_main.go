@@ -12,21 +12,23 @@ import (
 )
 
 func main() {
-	// 從環境變數獲取 Gemini API key
+	// 從環境變數獲取 Gemini API key；未設定時使用規則式分析
 	geminiAPIKey := os.Getenv("GEMINI_API_KEY")
-	
-	if geminiAPIKey == "" {
-		log.Fatal("請設定 GEMINI_API_KEY 環境變數")
-	}
 
 	ctx := context.Background()
 
-	// 初始化 Gemini 客戶端
-	geminiClient, err := gemini.NewGeminiClient(ctx, geminiAPIKey, "gemini-2.0-flash-exp")
-	if err != nil {
-		log.Fatalf("Failed to create Gemini client: %v", err)
+	var analyzer gemini.Analyzer = gemini.NewRuleAnalyzer()
+	if geminiAPIKey == "" {
+		fmt.Println("未設定 GEMINI_API_KEY，使用規則式分析")
+	} else {
+		// 初始化 Gemini 客戶端
+		geminiClient, err := gemini.NewGeminiClient(ctx, geminiAPIKey, "gemini-2.0-flash-exp")
+		if err != nil {
+			log.Fatalf("Failed to create Gemini client: %v", err)
+		}
+		defer geminiClient.Close()
+		analyzer = geminiClient
 	}
-	defer geminiClient.Close()
 
 	// 測試地址
 	testAddresses := []string{
@@ -47,19 +49,19 @@ func main() {
 		fmt.Printf("\n測試 %d: %s\n", i+1, address)
 		fmt.Println(strings.Repeat("-", 40))
 		
-		testGeminiProcessing(ctx, geminiClient, address)
+		testGeminiProcessing(ctx, analyzer, address)
 	}
 
 	fmt.Println("\n" + strings.Repeat("=", 60))
 	fmt.Println("測試完成")
 }
 
-func testGeminiProcessing(ctx context.Context, geminiClient *gemini.GeminiClient, text string) {
+func testGeminiProcessing(ctx context.Context, analyzer gemini.Analyzer, text string) {
 	fmt.Printf("📍 原始輸入: %s\n", text)
 
 	// Step 1: Gemini 意圖分析
 	fmt.Println("\n1️⃣ Gemini 意圖分析...")
-	intent, err := geminiClient.AnalyzeIntent(ctx, text)
+	intent, err := analyzer.AnalyzeIntent(ctx, text)
 	if err != nil {
 		fmt.Printf("   ❌ 意圖分析失敗: %v\n", err)
 	} else {
@@ -67,12 +69,12 @@ func testGeminiProcessing(ctx context.Context, geminiClient *gemini.GeminiClient
 		fmt.Printf("      District: '%s'\n", intent.District)
 		fmt.Printf("      TimeWindow: From='%s', To='%s'\n", intent.TimeWindow.From, intent.TimeWindow.To)
 		fmt.Printf("      Keywords: %v\n", intent.Keywords)
-		fmt.Printf("      QueryType: '%s'\n", intent.QueryType)
+		fmt.Printf("      Intent: '%s'\n", intent.Intent)
 	}
 
 	// Step 2: Gemini 地址提取
 	fmt.Println("\n2️⃣ Gemini 地址提取...")
-	extractedLocation, err := geminiClient.ExtractLocationFromText(ctx, text)
+	extractedLocation, err := analyzer.ExtractLocationFromText(ctx, text)
 	if err != nil {
 		fmt.Printf("   ❌ 地址提取失敗: %v\n", err)
 	} else {
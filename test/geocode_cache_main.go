@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linebot-garbage-helper/internal/geo"
+)
+
+// memoryStore 模擬 Firestore 持久化快取
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]geo.Location
+	expires map[string]time.Time
+}
+
+func (s *memoryStore) GetGeocode(ctx context.Context, key string) (*geo.Location, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	location, ok := s.entries[key]
+	if !ok {
+		return nil, time.Time{}, nil
+	}
+	return &location, s.expires[key], nil
+}
+
+func (s *memoryStore) SaveGeocode(ctx context.Context, key string, location *geo.Location, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = *location
+	s.expires[key] = expiresAt
+	return nil
+}
+
+// 同一地址與同時間的重複查詢只應呼叫一次地理編碼 API
+func main() {
+	fmt.Println("地理編碼快取測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	ctx := context.Background()
+	store := &memoryStore{entries: map[string]geo.Location{}, expires: map[string]time.Time{}}
+	cache := geo.NewGeocodeCache(geo.CacheConfig{Store: store})
+
+	var calls atomic.Int32
+	fetch := func(ctx context.Context) (*geo.Location, error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return &geo.Location{Lat: 25.0330, Lng: 121.5654, Address: "台北市信義區"}, nil
+	}
+
+	failed := 0
+	check := func(ok bool, message string) {
+		if ok {
+			fmt.Printf("✅ %s\n", message)
+		} else {
+			fmt.Printf("❌ %s\n", message)
+			failed++
+		}
+	}
+
+	// 同時 10 個相同查詢
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Do(ctx, geo.AddressKey("臺北市信義區"), time.Hour, fetch)
+		}()
+	}
+	wg.Wait()
+	check(calls.Load() == 1, fmt.Sprintf("10 個同時查詢只呼叫 API %d 次", calls.Load()))
+
+	// 寫法不同的同一地址
+	for _, address := range []string{"台北市信義區", " 台北市信義區 ", "臺北市信義區"} {
+		cache.Do(ctx, geo.AddressKey(address), time.Hour, fetch)
+	}
+	check(calls.Load() == 1, fmt.Sprintf("正規化後相同的地址共用快取（%s）", geo.AddressKey(" 臺北市信義區 ")))
+
+	// 相距幾公尺的分享位置
+	check(geo.CoordinateKey(25.03301, 121.56541) == geo.CoordinateKey(25.03304, 121.56538),
+		"相距幾公尺的座標使用同一個快取鍵")
+	check(geo.CoordinateKey(25.0330, 121.5654) != geo.CoordinateKey(25.0340, 121.5654),
+		"相距約 100 公尺的座標使用不同快取鍵")
+
+	// 過期後重新查詢
+	cache.Do(ctx, "short", time.Millisecond, fetch)
+	time.Sleep(5 * time.Millisecond)
+	cache.Do(ctx, "short", time.Millisecond, fetch)
+	check(calls.Load() == 3, "過期的快取會重新查詢")
+
+	// 重新啟動後從持久化快取讀取
+	restarted := geo.NewGeocodeCache(geo.CacheConfig{Store: store})
+	restarted.Do(ctx, geo.AddressKey("台北市信義區"), time.Hour, fetch)
+	check(calls.Load() == 3, "重新啟動後從持久化快取取得結果")
+
+	stats := cache.Stats()
+	fmt.Printf("\n📊 hits=%d storeHits=%d misses=%d coalesced=%d entries=%d\n",
+		stats.Hits, stats.StoreHits, stats.Misses, stats.Coalesced, stats.Entries)
+	check(stats.Misses == 3 && stats.Coalesced == 9 && stats.Hits == 3, "統計數字正確")
+	check(restarted.Stats().StoreHits == 1, "持久化快取命中有計入統計")
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("✨ 快取運作正常！")
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// 匯出 GTFS zip 後讀回來，檢查收運日、假日例外與跨午夜的時間
+func main() {
+	fmt.Println("GTFS 匯出測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	provider := garbage.NewTaipeiProvider("internal/garbage/testdata/taipei.json")
+	points, err := provider.Fetch(context.Background(), &http.Client{Timeout: 10 * time.Second})
+	if err != nil {
+		fmt.Printf("❌ 讀取失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 加一班跨午夜的夜間車
+	night := points[0]
+	night.ID = "taipei-99"
+	night.VehicleNumber = "KES-1099"
+	night.Route = "大安區夜間路線"
+	night.ArrivalTime = "2355"
+	night.DepartureTime = "0005"
+	points = append(points, night)
+	data := &garbage.GarbageData{Result: garbage.GarbageResult{Count: len(points), Results: points}}
+
+	calendar := garbage.NewCollectionCalendar()
+	if err := calendar.LoadHolidayDir("data/holidays"); err != nil {
+		fmt.Printf("❌ 讀取假日設定失敗: %v\n", err)
+		os.Exit(1)
+	}
+	// 夜間車只收週日，並在 1/4（週日）停收
+	calendar.SetRouteDays("台北市:KES-1099_第1車", garbage.NewWeekdays(time.Sunday))
+	calendar.AddSpecialDay("台北市", garbage.SpecialDay{
+		Date: "2026-01-04", Type: garbage.SpecialDaySuspended, Note: "夜間車停收", Routes: []string{"KES-1099_第1車"},
+	})
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, utils.GetTaiwanTimezone())
+	var buf bytes.Buffer
+	if err := garbage.BuildGTFSFeed(data, calendar, start).WriteZip(&buf); err != nil {
+		fmt.Printf("❌ 匯出失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	files, err := readZip(buf.Bytes())
+	if err != nil {
+		fmt.Printf("❌ 讀取 zip 失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, name := range []string{"agency.txt", "stops.txt", "routes.txt", "trips.txt", "stop_times.txt", "calendar.txt", "calendar_dates.txt"} {
+		if len(files[name]) < 2 {
+			fmt.Printf("❌ %s 沒有資料\n", name)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %-20s %d 筆\n", name, len(files[name])-1)
+	}
+
+	// 每個車次都要對到 calendar.txt 的收運日
+	calendarRows := make(map[string]string)
+	for _, row := range files["calendar.txt"][1:] {
+		calendarRows[row[0]] = strings.Join(row[1:8], "")
+	}
+	services := make(map[string]string)
+	for _, row := range files["trips.txt"][1:] {
+		services[row[2]] = row[1]
+	}
+	for trip, want := range map[string]string{
+		"台北市:KES-1021_第1車": "1101110",
+		"台北市:KES-1099_第1車": "0000001",
+	} {
+		if got := calendarRows[services[trip]]; got != want {
+			fmt.Printf("❌ %s 收運日 %q，預期 %q\n", trip, got, want)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %s 收運日 %s（%s）\n", trip, want, services[trip])
+	}
+
+	// 春節停收、加收寫進 calendar_dates.txt，限定路線的停收只影響夜間車
+	dates := make(map[string]bool)
+	for _, row := range files["calendar_dates.txt"][1:] {
+		dates[row[0]+" "+row[1]+" "+row[2]] = true
+	}
+	citywide := services["台北市:KES-1021_第1車"]
+	nightly := services["台北市:KES-1099_第1車"]
+	for _, c := range []struct {
+		entry string
+		want  bool
+	}{
+		{citywide + " 20260215 1", true},
+		{citywide + " 20260217 2", true},
+		{citywide + " 20260218 2", false}, // 週三本來就不收
+		{citywide + " 20260219 1", false}, // 週四本來就有收
+		{citywide + " 20260104 2", false},
+		{nightly + " 20260104 2", true},
+		{nightly + " 20260215 1", false}, // 週日本來就有收
+	} {
+		if dates[c.entry] != c.want {
+			fmt.Printf("❌ calendar_dates %s 應為 %v\n", c.entry, c.want)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ calendar_dates %-40s %v\n", c.entry, c.want)
+	}
+
+	// 跨午夜的離開時間寫成隔日時間
+	for _, row := range files["stop_times.txt"][1:] {
+		if row[0] != "台北市:KES-1099_第1車" {
+			continue
+		}
+		if row[1] != "23:55:00" || row[2] != "24:05:00" {
+			fmt.Printf("❌ 跨午夜站點時間 %s～%s，預期 23:55:00～24:05:00\n", row[1], row[2])
+			failed++
+		} else {
+			fmt.Printf("✅ 跨午夜站點時間 %s～%s\n", row[1], row[2])
+		}
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		fmt.Printf("❌ %d 項失敗\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("✨ GTFS 匯出測試全部通過！")
+}
+
+func readZip(b []byte) (map[string][][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][][]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		rows, err := csv.NewReader(rc).ReadAll()
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		files[f.Name] = rows
+	}
+	return files, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// 跨午夜的車次匯出 GTFS 後，stop_times 的時間必須一路遞增
+func main() {
+	fmt.Println("GTFS 跨午夜車次測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	stops := []struct {
+		location, arrival, departure, lat, lng string
+	}{
+		{"臺北市大安區復興南路一段390號", "2340", "2345", "25.033500", "121.543700"},
+		{"臺北市大安區信義路四段1號", "2350", "0005", "25.033800", "121.545100"},
+		{"臺北市大安區敦化南路二段77號", "0020", "0025", "25.029300", "121.548900"},
+		{"臺北市大安區和平東路三段1號", "0035", "0040", "25.025600", "121.550500"},
+	}
+
+	var points []garbage.CollectionPoint
+	for i, s := range stops {
+		points = append(points, garbage.CollectionPoint{
+			ID:            fmt.Sprintf("taipei-%d", 900+i),
+			City:          "台北市",
+			District:      "大安區",
+			VehicleNumber: "KES-1200",
+			Route:         "大安區深夜路線",
+			VehicleTrip:   "第1車",
+			ArrivalTime:   s.arrival,
+			DepartureTime: s.departure,
+			Location:      s.location,
+			Latitude:      s.lat,
+			Longitude:     s.lng,
+		})
+	}
+	data := &garbage.GarbageData{Result: garbage.GarbageResult{Count: len(points), Results: points}}
+
+	failed := 0
+
+	// 路線要從 23:40 開始，而不是依時鐘排在 00:20 之後
+	routeKey := garbage.RouteKey("台北市", "KES-1200", "第1車")
+	route := data.Routes()[routeKey]
+	if route == nil || route.Stops[0].Time != "2340" || route.Stops[len(route.Stops)-1].Time != "0035" {
+		fmt.Printf("❌ 路線順序錯誤: %+v\n", route)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ 路線順序 %s → %s\n", route.Stops[0].Time, route.Stops[len(route.Stops)-1].Time)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, utils.GetTaiwanTimezone())
+	var buf bytes.Buffer
+	if err := garbage.BuildGTFSFeed(data, garbage.NewCollectionCalendar(), start).WriteZip(&buf); err != nil {
+		fmt.Printf("❌ 匯出失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		fmt.Printf("❌ 讀取 zip 失敗: %v\n", err)
+		os.Exit(1)
+	}
+	var rows [][]string
+	for _, f := range zr.File {
+		if f.Name != "stop_times.txt" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			fmt.Printf("❌ 讀取 stop_times.txt 失敗: %v\n", err)
+			os.Exit(1)
+		}
+		rows, err = csv.NewReader(rc).ReadAll()
+		rc.Close()
+		if err != nil {
+			fmt.Printf("❌ 解析 stop_times.txt 失敗: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	want := [][2]string{
+		{"23:40:00", "23:45:00"},
+		{"23:50:00", "24:05:00"},
+		{"24:20:00", "24:25:00"},
+		{"24:35:00", "24:40:00"},
+	}
+	if len(rows)-1 != len(want) {
+		fmt.Printf("❌ stop_times 有 %d 筆，預期 %d 筆\n", len(rows)-1, len(want))
+		os.Exit(1)
+	}
+
+	previous := ""
+	for i, row := range rows[1:] {
+		arrival, departure := row[1], row[2]
+		switch {
+		case arrival != want[i][0] || departure != want[i][1]:
+			fmt.Printf("❌ 第 %s 站 %s～%s，預期 %s～%s\n", row[4], arrival, departure, want[i][0], want[i][1])
+			failed++
+		case arrival < previous:
+			fmt.Printf("❌ 第 %s 站 %s 早於前一站離開 %s\n", row[4], arrival, previous)
+			failed++
+		default:
+			fmt.Printf("✅ 第 %s 站 %s～%s\n", row[4], arrival, departure)
+		}
+		previous = departure
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		fmt.Printf("❌ %d 項失敗\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("✨ 跨午夜車次的時間一路遞增！")
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// 驗證國定假日停收與加收：停收日會被跳過，加收日即使是週三、週日也會收運
+func main() {
+	fmt.Println("假日收運測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	tz := utils.GetTaiwanTimezone()
+	at := func(month, day, hour int) time.Time {
+		return time.Date(2026, time.Month(month), day, hour, 0, 0, 0, tz)
+	}
+
+	point := &garbage.CollectionPoint{
+		City:          "台北市",
+		VehicleNumber: "KES-1021",
+		VehicleTrip:   "第1車",
+		ArrivalTime:   "1900",
+		DepartureTime: "1905",
+		Location:      "臺北市大安區新生南路二段30號",
+	}
+	other := *point
+	other.VehicleNumber = "KES-2001"
+
+	failed := 0
+	check := func(name string, got, want time.Time, err error) {
+		if err != nil || !got.Equal(want) {
+			fmt.Printf("❌ %-24s → %s (%v)，預期 %s\n", name, got.Format("01/02 Mon 15:04"), err, want.Format("01/02 Mon 15:04"))
+			failed++
+			return
+		}
+		fmt.Printf("✅ %-24s → %s\n", name, got.Format("01/02 Mon 15:04"))
+	}
+
+	// data/holidays 的春節設定：初一、初二停收，除夕前的週日與初三加收
+	calendar := garbage.NewCollectionCalendar()
+	if err := calendar.LoadHolidayDir("data/holidays"); err != nil {
+		fmt.Printf("❌ 讀取假日設定失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	arrival, err := calendar.NextArrival(point, at(2, 15, 12))
+	check("週日加收", arrival, at(2, 15, 19), err)
+
+	arrival, err = calendar.NextArrival(point, at(2, 16, 20))
+	check("初一初二停收", arrival, at(2, 19, 19), err)
+
+	if notice, ok := calendar.SuspensionBefore(point, at(2, 16, 20), arrival); !ok || notice.Note != "春節初一" {
+		fmt.Printf("❌ 停收說明 → %+v，預期 春節初一\n", notice)
+		failed++
+	} else {
+		fmt.Printf("✅ 停收說明 → %s %s\n", notice.Date, notice.Note)
+	}
+
+	// 同一天同時有全市停收與限定路線加收，兩筆都要生效
+	calendar = garbage.NewCollectionCalendar()
+	calendar.AddSpecialDay("台北市", garbage.SpecialDay{Date: "2026-10-10", Type: garbage.SpecialDaySuspended, Note: "國慶日"})
+	calendar.AddSpecialDay("台北市", garbage.SpecialDay{
+		Date:   "2026-10-10",
+		Type:   garbage.SpecialDayExtra,
+		Note:   "國慶日加收",
+		Routes: []string{garbage.RouteKey(point.City, point.VehicleNumber, point.VehicleTrip)},
+	})
+	calendar.AddSpecialDay("台北市", garbage.SpecialDay{Date: "2026-10-14", Type: garbage.SpecialDayExtra, Note: "週三加收"})
+
+	for _, c := range []struct {
+		name  string
+		point *garbage.CollectionPoint
+		day   time.Time
+		want  bool
+	}{
+		{"限定路線加收", point, at(10, 10, 0), true},
+		{"其他路線停收", &other, at(10, 10, 0), false},
+		{"全市週三加收", &other, at(10, 14, 0), true},
+		{"一般週四", &other, at(10, 15, 0), true},
+		{"一般週日", &other, at(10, 18, 0), false},
+	} {
+		if got := calendar.IsServiceDay(c.point, c.day); got != c.want {
+			fmt.Printf("❌ %-20s %s → %v，預期 %v\n", c.name, c.day.Format("01/02 Mon"), got, c.want)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %-20s %s → %v\n", c.name, c.day.Format("01/02 Mon"), c.want)
+	}
+
+	arrival, err = calendar.NextArrival(&other, at(10, 9, 20))
+	check("其他路線跳過國慶日", arrival, at(10, 12, 19), err)
+
+	// 後加入的設定不會蓋掉另一個範圍的設定
+	calendar = garbage.NewCollectionCalendar()
+	calendar.AddSpecialDay("台北市", garbage.SpecialDay{
+		Date:   "2026-10-10",
+		Type:   garbage.SpecialDayExtra,
+		Routes: []string{garbage.RouteKey(point.City, point.VehicleNumber, point.VehicleTrip)},
+	})
+	calendar.AddSpecialDay("台北市", garbage.SpecialDay{Date: "2026-10-10", Type: garbage.SpecialDaySuspended})
+	if !calendar.IsServiceDay(point, at(10, 10, 0)) || calendar.IsServiceDay(&other, at(10, 10, 0)) {
+		fmt.Println("❌ 加入順序不應影響路線與全市設定")
+		failed++
+	} else {
+		fmt.Println("✅ 加入順序不影響路線與全市設定")
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		fmt.Printf("❌ %d 項失敗\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("✨ 假日收運測試全部通過！")
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/geo"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// 使用者輸入的站點名稱或地標與資料中的寫法略有不同時，仍應找到同一個站點
+func main() {
+	fmt.Println("站點名稱模糊搜尋測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	adapter := garbage.NewGarbageAdapter(garbage.NewTaipeiProvider("internal/garbage/testdata/taipei.json"))
+	data, err := adapter.GetGarbageData(context.Background())
+	if err != nil {
+		fmt.Printf("❌ 讀取失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	index := data.LocationIndex()
+
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"全家 復興店", "全家便利商店 復興店"},
+		{"全家復興店", "全家便利商店 復興店"},
+		{"仁愛路二段 27巷口", "臺北市中正區仁愛路二段27巷口"},
+		{"仁愛路2段27巷", "臺北市中正區仁愛路二段27巷口"},
+		{"仁愛路二段 27 巷", "臺北市中正區仁愛路二段27巷口"},
+		{"新生南路二段３０號", "臺北市大安區新生南路二段30號"},
+		{"麗水街13巷", "臺北市大安區麗水街13巷口"},
+		{"仁愛路二段 29巷口", ""},
+		{"麗水街15巷", ""},
+		{"家", ""},
+		{"忠孝東路四段", ""},
+	}
+
+	failed := 0
+	for _, c := range cases {
+		matches := index.Search(c.query)
+
+		got := ""
+		if len(matches) > 0 {
+			got = matches[0].Group.Name
+		}
+		if got != c.want {
+			fmt.Printf("❌ %-20s → %q，預期 %q\n", c.query, got, c.want)
+			failed++
+			continue
+		}
+		if got == "" {
+			fmt.Printf("✅ %-20s → 交給地理編碼\n", c.query)
+		} else {
+			fmt.Printf("✅ %-20s → %s（%.2f）\n", c.query, got, matches[0].Score)
+		}
+	}
+
+	// 完整門牌地址只接受同一門牌的站點，共用路名的站點交給地理編碼
+	addresses := []struct {
+		query string
+		want  string
+	}{
+		{"新生南路二段30號", "臺北市大安區新生南路二段30號"},
+		{"台北市大安區新生南路２段30號", "臺北市大安區新生南路二段30號"},
+		{"大安區新生南路30號", ""},
+		{"中正區新生南路二段30號", ""},
+	}
+	for _, c := range addresses {
+		matches := garbage.MatchesAtAddress(index.Search(c.query), geo.ParseAddress(c.query))
+
+		got := ""
+		if len(matches) > 0 {
+			got = matches[0].Group.Name
+		}
+		if got != c.want {
+			fmt.Printf("❌ %-20s → %q，預期 %q\n", c.query, got, c.want)
+			failed++
+			continue
+		}
+		if got == "" {
+			fmt.Printf("✅ %-20s → 門牌不同，交給地理編碼\n", c.query)
+		} else {
+			fmt.Printf("✅ %-20s → 同一門牌 %s\n", c.query, got)
+		}
+	}
+
+	// 「全家復興店來之前提醒我」直接提醒最近一班抵達的車次
+	now := time.Date(2026, 10, 16, 18, 0, 0, 0, utils.GetTaiwanTimezone())
+	best := garbage.BestMatches(index.Search("全家復興店"))
+	if len(best) != 1 {
+		fmt.Printf("❌ 全家復興店 應只對應一個站點，得到 %d 個\n", len(best))
+		failed++
+	} else if stop, err := adapter.NextVisit(data, best[0].Group, now); err != nil {
+		fmt.Printf("❌ 全家復興店 下一班: %v\n", err)
+		failed++
+	} else if got := stop.ETA.Format("2006-01-02 15:04"); stop.Route.ID != garbage.RouteKey("台北市", "KEP-2033", "第1車") || got != "2026-10-16 18:45" {
+		fmt.Printf("❌ 全家復興店 下一班 %s %s，預期 KEP-2033 第1車 2026-10-16 18:45\n", stop.Route.ID, got)
+		failed++
+	} else {
+		fmt.Printf("✅ 全家復興店 下一班 %s %s\n", stop.Route.ID, got)
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("✨ 所有查詢都符合預期！")
+}
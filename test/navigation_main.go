@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"linebot-garbage-helper/internal/geo"
+)
+
+// 驗證三種地圖的步行導航連結都帶有起點、終點與步行模式
+func main() {
+	fmt.Println("導航連結測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	origin := &geo.Point{Lat: 25.033964, Lng: 121.543872}
+	lat, lng := 25.030012, 121.533018
+
+	cases := []struct {
+		provider geo.MapProvider
+		origin   *geo.Point
+		host     string
+		want     map[string]string
+	}{
+		{geo.MapProviderGoogle, origin, "www.google.com", map[string]string{
+			"origin": "25.033964,121.543872", "destination": "25.030012,121.533018", "travelmode": "walking",
+		}},
+		{geo.MapProviderApple, origin, "maps.apple.com", map[string]string{
+			"saddr": "25.033964,121.543872", "daddr": "25.030012,121.533018", "dirflg": "w",
+		}},
+		{geo.MapProviderOSM, origin, "www.openstreetmap.org", map[string]string{
+			"route": "25.033964,121.543872;25.030012,121.533018", "engine": "fossgis_osrm_foot",
+		}},
+		{geo.MapProviderGoogle, nil, "www.google.com", map[string]string{
+			"origin": "", "destination": "25.030012,121.533018",
+		}},
+		{geo.MapProviderOSM, nil, "www.openstreetmap.org", map[string]string{
+			"route": ";25.030012,121.533018",
+		}},
+		{geo.MapProvider("unknown"), nil, "www.google.com", map[string]string{
+			"travelmode": "walking",
+		}},
+	}
+
+	failed := 0
+	for _, c := range cases {
+		link := geo.WalkingDirectionsURL(c.provider, c.origin, lat, lng)
+		u, err := url.Parse(link)
+		ok := err == nil && u.Host == c.host
+		for key, want := range c.want {
+			if ok && u.Query().Get(key) != want {
+				ok = false
+			}
+		}
+		mark := "✅"
+		if !ok {
+			mark = "❌"
+			failed++
+		}
+		fmt.Printf("%s %-10s origin=%-5v %s\n", mark, c.provider, c.origin != nil, link)
+	}
+
+	fmt.Println()
+	for input, want := range map[string]geo.MapProvider{
+		"google": geo.MapProviderGoogle, "Apple": geo.MapProviderApple,
+		"openstreetmap": geo.MapProviderOSM, " osm ": geo.MapProviderOSM, "here": "",
+	} {
+		got, _ := geo.ParseMapProvider(input)
+		mark := "✅"
+		if got != want {
+			mark = "❌"
+			failed++
+		}
+		fmt.Printf("%s ParseMapProvider(%q) = %q\n", mark, input, got)
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		fmt.Printf("❌ %d 項失敗\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("✨ 導航連結正確！")
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"linebot-garbage-helper/internal/gemini"
+)
+
+// 規則式分析不需要 API key，結果固定，可驗證意圖、參數與地區；時間範圍見 time_window_main.go
+func main() {
+	fmt.Println("規則式語意分析測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	ctx := context.Background()
+	analyzer := gemini.NewRuleAnalyzer()
+
+	cases := []struct {
+		text     string
+		intent   gemini.IntentType
+		slots    gemini.IntentSlots
+		district string
+	}{
+		{"你好", gemini.IntentGreeting, gemini.IntentSlots{}, ""},
+		{"怎麼用？", gemini.IntentHelp, gemini.IntentSlots{}, ""},
+		{"台北市中正區重慶南路一段122號", gemini.IntentFindTrucks, gemini.IntentSlots{}, "台北市中正區"},
+		{"我晚上七點前在台北市大安區哪裡倒垃圾？", gemini.IntentFindTrucks, gemini.IntentSlots{}, "台北市大安區"},
+		{"晚上六點半在哪裡倒垃圾？", gemini.IntentFindTrucks, gemini.IntentSlots{}, ""},
+		{"下午3:15以後的垃圾車", gemini.IntentFindTrucks, gemini.IntentSlots{}, ""},
+		{"晚上七點到九點", gemini.IntentFindTrucks, gemini.IntentSlots{}, ""},
+		{"幫我把這裡存成公司", gemini.IntentAddFavorite, gemini.IntentSlots{FavoriteName: "公司"}, ""},
+		{"收藏台北市大安區新生南路二段30號叫娘家", gemini.IntentAddFavorite, gemini.IntentSlots{FavoriteName: "娘家", Address: "台北市大安區新生南路二段30號"}, "台北市大安區"},
+		{"把這裡設成公司", gemini.IntentAddFavorite, gemini.IntentSlots{FavoriteName: "公司"}, ""},
+		// 設成、設為前面不是地點時不是收藏
+		{"提醒設成七點", gemini.IntentFindTrucks, gemini.IntentSlots{}, ""},
+		{"把排序設為最近", gemini.IntentFindTrucks, gemini.IntentSlots{}, ""},
+		{"刪除收藏 公司", gemini.IntentDeleteFavorite, gemini.IntentSlots{FavoriteName: "公司"}, ""},
+		{"把家從收藏刪掉", gemini.IntentDeleteFavorite, gemini.IntentSlots{FavoriteName: "家"}, ""},
+		{"我的收藏", gemini.IntentListFavorites, gemini.IntentSlots{}, ""},
+		{"取消明天的提醒", gemini.IntentCancelReminder, gemini.IntentSlots{Day: "tomorrow"}, ""},
+		{"垃圾車來之前提醒我", gemini.IntentSetReminder, gemini.IntentSlots{}, ""},
+		{"全家復興店的垃圾車來之前提醒我", gemini.IntentSetReminder, gemini.IntentSlots{Stop: "全家復興店"}, ""},
+		{"新生南路二段30號垃圾車快到時通知我", gemini.IntentSetReminder, gemini.IntentSlots{Stop: "新生南路二段30號"}, ""},
+		// 只有地區時照一般查詢處理，從結果中選站點
+		{"信義區的垃圾車來之前提醒我", gemini.IntentSetReminder, gemini.IntentSlots{}, "信義區"},
+		{"哪一個最快到？", gemini.IntentSortResults, gemini.IntentSlots{Sort: "soonest"}, ""},
+		{"離我最近的是哪個", gemini.IntentSortResults, gemini.IntentSlots{Sort: "closest"}, ""},
+		{"最近的垃圾車在哪", gemini.IntentSortResults, gemini.IntentSlots{Sort: "closest"}, ""},
+		{"推薦哪一班", gemini.IntentSortResults, gemini.IntentSlots{Sort: "best"}, ""},
+		// 有地點時仍是查詢，排序作為附帶條件
+		{"全家復興店最早幾點來", gemini.IntentFindTrucks, gemini.IntentSlots{Sort: "soonest"}, ""},
+		{"信義區哪個最近", gemini.IntentFindTrucks, gemini.IntentSlots{Sort: "closest"}, "信義區"},
+		{"新生南路二段30號最快什麼時候到", gemini.IntentFindTrucks, gemini.IntentSlots{Sort: "soonest"}, ""},
+	}
+
+	failed := 0
+	for _, c := range cases {
+		result, err := analyzer.AnalyzeIntent(ctx, c.text)
+		if err != nil {
+			fmt.Printf("❌ %s → 錯誤：%v\n", c.text, err)
+			failed++
+			continue
+		}
+		if result.Intent != c.intent || result.Slots != c.slots || result.District != c.district {
+			fmt.Printf("❌ %s\n   得到 %s %+v %q\n   預期 %s %+v %q\n", c.text,
+				result.Intent, result.Slots, result.District,
+				c.intent, c.slots, c.district)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %-28s → %s\n", c.text, result.Intent)
+	}
+
+	fmt.Println()
+	locations := map[string]string{
+		"我住在台北市大安區新生南路２段３０號３樓": "台北市大安區新生南路二段30號",
+		"信義區附近的垃圾車幾點來？":        "信義區",
+		"全家復興店附近有垃圾車嗎":         "全家復興店",
+		"幾點？":                  "",
+	}
+	for text, want := range locations {
+		got, err := analyzer.ExtractLocationFromText(ctx, text)
+		if err != nil || got != want {
+			fmt.Printf("❌ 抽取地點 %s → %q，預期 %q\n", text, got, want)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ 抽取地點 %s → %q\n", text, got)
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		fmt.Printf("❌ %d 項失敗\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("✨ 規則式分析結果正確！")
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/geo"
+)
+
+// quotaExceeded 模擬 Maps API 額度用完
+type quotaExceeded struct{}
+
+func (quotaExceeded) GeocodeAddress(ctx context.Context, address string) (*geo.Location, error) {
+	return nil, errors.New("maps: OVER_QUERY_LIMIT")
+}
+
+func (quotaExceeded) ReverseGeocode(ctx context.Context, lat, lng float64) (*geo.Location, error) {
+	return nil, errors.New("maps: OVER_QUERY_LIMIT")
+}
+
+// 離線地理編碼使用地址表與資料集的行政區／里中心點，不需要 Maps API key
+func main() {
+	fmt.Println("離線地理編碼測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	ctx := context.Background()
+	adapter := garbage.NewGarbageAdapter(garbage.NewTaipeiProvider("internal/garbage/testdata/taipei.json"))
+	data, err := adapter.GetGarbageData(ctx)
+	if err != nil {
+		fmt.Printf("❌ 讀取失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	table, err := geo.LoadAddressTable("data/addresses.json")
+	if err != nil {
+		fmt.Printf("❌ 讀取地址表失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	offline := geo.NewOfflineGeocoder(table)
+	offline.SetAreas(data.Gazetteer().Places())
+	geocoder := geo.NewFallbackGeocoder(quotaExceeded{}, offline)
+
+	cases := []struct {
+		address string
+		want    string
+	}{
+		{"臺北市大安區新生南路二段30號", "台北市大安區"},
+		{"大安區龍安里", "大安區龍安里"},
+		{"台北市中正區仁愛路", "台北市中正區"},
+		{"台北 101", "台北101"},
+		{"火星基地", ""},
+	}
+
+	failed := 0
+	for _, c := range cases {
+		location, err := geocoder.GeocodeAddress(ctx, c.address)
+
+		got := ""
+		if err == nil {
+			got = location.Address
+		}
+		if got != c.want {
+			fmt.Printf("❌ %-20s → %q，預期 %q（%v）\n", c.address, got, c.want, err)
+			failed++
+			continue
+		}
+		if err != nil {
+			fmt.Printf("✅ %-20s → 找不到\n", c.address)
+		} else {
+			fmt.Printf("✅ %-20s → %s（%.4f, %.4f）\n", c.address, got, location.Lat, location.Lng)
+		}
+	}
+
+	location, err := geocoder.ReverseGeocode(ctx, 25.0301, 121.5331)
+	if err != nil || !strings.HasSuffix(location.Address, "龍安里") {
+		fmt.Printf("❌ 反查座標應為龍安里：%v %v\n", location, err)
+		failed++
+	} else {
+		fmt.Printf("✅ 25.0301, 121.5331 → %s\n", location.Address)
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("✨ 離線地理編碼運作正常！")
+}
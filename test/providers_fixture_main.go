@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/garbage"
+)
+
+// 使用 internal/garbage/testdata 的範例資料驗證各縣市 provider 的解析結果
+func main() {
+	fmt.Println("縣市資料 Provider 測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	fixtureDir := "internal/garbage/testdata"
+	providers := []struct {
+		provider garbage.Provider
+		expected int
+	}{
+		{garbage.NewTaipeiProvider(fixtureDir + "/taipei.json"), 6},
+		{garbage.NewNTPCProvider(fixtureDir + "/new_taipei.json"), 3},
+		{garbage.NewTaoyuanProvider(fixtureDir + "/taoyuan.json"), 2},
+		{garbage.NewKaohsiungProvider(fixtureDir + "/kaohsiung.json"), 2},
+	}
+
+	ctx := context.Background()
+	client := &http.Client{Timeout: 10 * time.Second}
+	failed := 0
+	// 不同縣市的站點編號與車次不可互相覆蓋
+	ids := make(map[string]string)
+	var all []garbage.CollectionPoint
+
+	for _, tc := range providers {
+		p := tc.provider
+		fmt.Printf("\n🧪 %s (%s)\n", p.Name(), p.City())
+
+		points, err := p.Fetch(ctx, client)
+		if err != nil {
+			fmt.Printf("❌ 讀取失敗: %v\n", err)
+			failed++
+			continue
+		}
+
+		if len(points) != tc.expected {
+			fmt.Printf("❌ 站點數量 %d，預期 %d\n", len(points), tc.expected)
+			failed++
+			continue
+		}
+
+		for _, point := range points {
+			if point.City != p.City() || point.Location == "" || point.ArrivalTime == "" {
+				fmt.Printf("❌ 欄位轉換不完整: %+v\n", point)
+				failed++
+				break
+			}
+		}
+
+		for _, point := range points {
+			if city, ok := ids[point.ID]; ok && city != point.City {
+				fmt.Printf("❌ 站點編號 %s 與%s重複\n", point.ID, city)
+				failed++
+			}
+			ids[point.ID] = point.City
+		}
+		all = append(all, points...)
+
+		first := points[0]
+		fmt.Printf("✅ %d 個站點，第一筆：%s %s %s 抵達 %s (%s, %s)\n",
+			len(points), first.District, first.Route, first.Location, first.ArrivalTime, first.Latitude, first.Longitude)
+	}
+
+	// 同一組車號車次出現在兩個縣市時，仍是兩條路線
+	shared := all[0]
+	shared.City = "高雄市"
+	all = append(all, shared)
+	routes := garbage.BuildRoutes(all)
+	taipeiKey := garbage.RouteKey(all[0].City, all[0].VehicleNumber, all[0].VehicleTrip)
+	kaohsiungKey := garbage.RouteKey(shared.City, shared.VehicleNumber, shared.VehicleTrip)
+	if routes[taipeiKey] == nil || routes[kaohsiungKey] == nil || taipeiKey == kaohsiungKey {
+		fmt.Printf("❌ 跨縣市相同車次被合併: %s / %s\n", taipeiKey, kaohsiungKey)
+		failed++
+	} else {
+		fmt.Printf("\n✅ 跨縣市相同車次分開：%s、%s\n", taipeiKey, kaohsiungKey)
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		fmt.Printf("❌ %d 個 provider 測試失敗\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("✨ 所有 provider 測試通過！")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// 以固定時間驗證三種排序：最快抵達、最近、綜合步行與等待時間的最佳排序
+func main() {
+	fmt.Println("站點排序測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	now := time.Date(2026, 3, 2, 18, 50, 0, 0, utils.GetTaiwanTimezone())
+	stop := func(name string, distance float64, etaMinutes int) *garbage.NearestStop {
+		eta := now.Add(time.Duration(etaMinutes) * time.Minute)
+		return &garbage.NearestStop{
+			Stop:      garbage.Stop{Name: name},
+			Distance:  distance,
+			ETA:       eta,
+			Departure: eta.Add(5 * time.Minute),
+			Status:    garbage.StopStatusUpcoming,
+		}
+	}
+
+	stops := []*garbage.NearestStop{
+		stop("巷口（很近但要等很久）", 80, 90),
+		stop("路口（走 5 分鐘、10 分鐘後到）", 400, 10),
+		stop("公園（很快到但走不到）", 800, 2),
+		stop("學校（遠但時間剛好）", 1200, 20),
+	}
+
+	expected := map[garbage.SortMode]string{
+		garbage.SortSoonest: "公園（很快到但走不到）",
+		garbage.SortClosest: "巷口（很近但要等很久）",
+		garbage.SortBest:    "路口（走 5 分鐘、10 分鐘後到）",
+	}
+
+	failed := 0
+	for _, mode := range []garbage.SortMode{garbage.SortSoonest, garbage.SortClosest, garbage.SortBest} {
+		ranked := append([]*garbage.NearestStop(nil), stops...)
+		garbage.RankStops(ranked, mode, now)
+
+		var names []string
+		for _, s := range ranked {
+			mark := ""
+			if !s.Catchable(now) {
+				mark = "✗"
+			}
+			names = append(names, fmt.Sprintf("%s%s(%.0f)", s.Stop.Name, mark, s.Score(now)))
+		}
+
+		if ranked[0].Stop.Name != expected[mode] {
+			fmt.Printf("❌ %s: 第一名 %s，預期 %s\n", mode, ranked[0].Stop.Name, expected[mode])
+			failed++
+		} else {
+			fmt.Printf("✅ %s: %s\n", mode, strings.Join(names, " > "))
+		}
+	}
+
+	if garbage.ParseSortMode("unknown") != garbage.SortBest {
+		fmt.Println("❌ 未知排序應預設為 best")
+		failed++
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("✨ 排序結果正確！")
+}
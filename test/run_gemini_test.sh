@@ -8,15 +8,10 @@ echo "===================="
 
 # 檢查是否設定了必要的環境變數
 if [ -z "$GEMINI_API_KEY" ]; then
-    echo "❌ 請設定 GEMINI_API_KEY 環境變數"
-    echo ""
-    echo "使用方式："
-    echo "export GEMINI_API_KEY='your_gemini_api_key_here'"
-    echo "./test/run_gemini_test.sh"
-    exit 1
+    echo "⚠️  未設定 GEMINI_API_KEY，改用規則式分析"
+else
+    echo "✅ GEMINI_API_KEY 已設定"
 fi
-
-echo "✅ GEMINI_API_KEY 已設定"
 echo ""
 
 # 進入專案根目錄
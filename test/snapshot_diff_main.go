@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/garbage"
+)
+
+// 修改範例資料後比較前後兩份快照，確認新增、移除與改時間的站點都能被偵測
+func main() {
+	fmt.Println("資料異動偵測測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	provider := garbage.NewTaipeiProvider("internal/garbage/testdata/taipei.json")
+	points, err := provider.Fetch(context.Background(), &http.Client{Timeout: 10 * time.Second})
+	if err != nil {
+		fmt.Printf("❌ 讀取失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	previous := garbage.BuildRoutes(points)
+
+	// 模擬上游資料更新：改一站的時間、移除一站、新增一站
+	updated := make([]garbage.CollectionPoint, 0, len(points))
+	for _, point := range points {
+		switch point.Location {
+		case "臺北市大安區和平東路一段100號":
+			point.ArrivalTime = "1912"
+		case "全家便利商店 復興店":
+			continue
+		}
+		updated = append(updated, point)
+	}
+	extra := points[0]
+	extra.ID = "taipei-99"
+	extra.Location = "臺北市大安區新生南路二段52號"
+	extra.ArrivalTime = "1930"
+	updated = append(updated, extra)
+
+	// 快照經過 Firestore 格式來回轉換後仍應一致
+	stored := make(map[string]*garbage.Route, len(previous))
+	for id, route := range previous {
+		stored[id] = garbage.RouteFromSnapshotData(garbage.RouteSnapshotData(route))
+	}
+	if diff := garbage.DiffRoutes(previous, stored); !diff.Empty() {
+		fmt.Printf("❌ 快照轉換後出現差異: %+v\n", diff)
+		os.Exit(1)
+	}
+	for id, route := range stored {
+		if route.City != previous[id].City {
+			fmt.Printf("❌ 快照轉換後縣市不同: %s %q\n", id, route.City)
+			os.Exit(1)
+		}
+	}
+	fmt.Println("✅ 快照轉換前後一致")
+
+	// 某縣市下載失敗時要標記出來，異動通知才不會把該縣市的站點當成全部取消
+	adapter := garbage.NewGarbageAdapter(provider, garbage.NewNTPCProvider("internal/garbage/testdata/missing.json"))
+	data, err := adapter.FetchGarbageData(context.Background())
+	if err != nil || len(data.FailedCities) != 1 || data.FailedCities[0] != "新北市" {
+		fmt.Printf("❌ 下載失敗的縣市: %v (%v)\n", data, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ 下載失敗的縣市: %s\n", strings.Join(data.FailedCities, ", "))
+
+	diff := garbage.DiffRoutes(stored, garbage.BuildRoutes(updated))
+
+	failed := 0
+	check := func(name string, changes []garbage.StopChange, location string) {
+		if len(changes) != 1 || changes[0].Location != location {
+			fmt.Printf("❌ %s: %+v\n", name, changes)
+			failed++
+			return
+		}
+		c := changes[0]
+		fmt.Printf("✅ %s: %s %s（%q → %q）\n", name, c.RouteID, c.Location, c.OldTime, c.NewTime)
+	}
+
+	check("新增", diff.Added, "臺北市大安區新生南路二段52號")
+	check("移除", diff.Removed, "全家便利商店 復興店")
+	check("改時間", diff.Changed, "臺北市大安區和平東路一段100號")
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Printf("✨ 受影響路線: %s\n", strings.Join(diff.RouteIDs(), ", "))
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"linebot-garbage-helper/internal/garbage"
+	"linebot-garbage-helper/internal/geo"
+)
+
+// 比較網格空間索引與原本逐筆計算距離的線性掃描
+// 執行方式：go run test/spatial_index_bench_main.go
+func main() {
+	const pointCount = 40000
+	const k = 5
+	const radius = 2000.0
+
+	rng := rand.New(rand.NewSource(1))
+	points := syntheticPoints(rng, pointCount)
+	index := garbage.NewSpatialIndex(points)
+
+	queries := make([][2]float64, 200)
+	for i := range queries {
+		queries[i] = [2]float64{24.98 + rng.Float64()*0.22, 121.46 + rng.Float64()*0.2}
+	}
+
+	fmt.Println("空間索引效能測試")
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Printf("資料筆數：%d，k=%d，半徑=%.0f 公尺\n\n", pointCount, k, radius)
+
+	// 先確認兩種做法結果一致
+	for _, q := range queries {
+		got := index.Nearest(q[0], q[1], k, nil)
+		want := linearNearest(points, q[0], q[1], k)
+		for i := range want {
+			if got[i].Index != want[i] {
+				fmt.Printf("❌ Nearest 結果不一致：query=%v got=%d want=%d\n", q, got[i].Index, want[i])
+				os.Exit(1)
+			}
+		}
+
+		if len(index.Within(q[0], q[1], radius, nil)) != len(linearWithin(points, q[0], q[1], radius)) {
+			fmt.Printf("❌ Within 結果數量不一致：query=%v\n", q)
+			os.Exit(1)
+		}
+	}
+	fmt.Println("✅ 索引查詢結果與線性掃描一致")
+
+	results := []struct {
+		name string
+		fn   func(b *testing.B)
+	}{
+		{"Nearest/linear", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				q := queries[i%len(queries)]
+				linearNearest(points, q[0], q[1], k)
+			}
+		}},
+		{"Nearest/index", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				q := queries[i%len(queries)]
+				index.Nearest(q[0], q[1], k, nil)
+			}
+		}},
+		{"Within/linear", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				q := queries[i%len(queries)]
+				linearWithin(points, q[0], q[1], radius)
+			}
+		}},
+		{"Within/index", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				q := queries[i%len(queries)]
+				index.Within(q[0], q[1], radius, nil)
+			}
+		}},
+	}
+
+	fmt.Println()
+	for _, r := range results {
+		res := testing.Benchmark(r.fn)
+		fmt.Printf("%-16s %12d ns/op %10d B/op\n", r.name, res.NsPerOp(), res.AllocedBytesPerOp())
+	}
+}
+
+func syntheticPoints(rng *rand.Rand, n int) []garbage.CollectionPoint {
+	points := make([]garbage.CollectionPoint, n)
+	for i := range points {
+		points[i] = garbage.CollectionPoint{
+			ID:          strconv.Itoa(i + 1),
+			City:        "台北市",
+			ArrivalTime: fmt.Sprintf("%02d%02d", 16+rng.Intn(6), rng.Intn(60)),
+			Latitude:    strconv.FormatFloat(24.96+rng.Float64()*0.25, 'f', 6, 64),
+			Longitude:   strconv.FormatFloat(121.45+rng.Float64()*0.22, 'f', 6, 64),
+		}
+	}
+	return points
+}
+
+// linearNearest 是原本 FindNearestStops 的做法：每筆解析座標、計算距離並整體排序
+func linearNearest(points []garbage.CollectionPoint, lat, lng float64, k int) []int {
+	type candidate struct {
+		index    int
+		distance float64
+	}
+
+	candidates := make([]candidate, 0, len(points))
+	for i, p := range points {
+		pLat, _ := strconv.ParseFloat(p.Latitude, 64)
+		pLng, _ := strconv.ParseFloat(p.Longitude, 64)
+		candidates = append(candidates, candidate{i, geo.CalculateDistance(lat, lng, pLat, pLng)})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	result := make([]int, 0, k)
+	for _, c := range candidates[:k] {
+		result = append(result, c.index)
+	}
+	return result
+}
+
+func linearWithin(points []garbage.CollectionPoint, lat, lng, radius float64) []int {
+	var result []int
+	for i, p := range points {
+		pLat, _ := strconv.ParseFloat(p.Latitude, 64)
+		pLng, _ := strconv.ParseFloat(p.Longitude, 64)
+		if geo.CalculateDistance(lat, lng, pLat, pLng) <= radius {
+			result = append(result, i)
+		}
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/garbage"
+)
+
+// 同一地點有多個車次時，查詢結果應合併成一個站點，站點詳情列出所有車次
+func main() {
+	fmt.Println("站點合併測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	adapter := garbage.NewGarbageAdapter(garbage.NewTaipeiProvider("internal/garbage/testdata/taipei.json"))
+	data, err := adapter.GetGarbageData(context.Background())
+	if err != nil {
+		fmt.Printf("❌ 讀取失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	const location = "臺北市大安區新生南路二段30號"
+
+	stops, err := adapter.FindNearestStops(25.0300, 121.5330, data, 5, time.Now())
+	if err != nil {
+		fmt.Printf("❌ 查詢失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := 0
+	seen := make(map[string]bool)
+	for _, stop := range stops {
+		if seen[stop.Stop.Name] {
+			fmt.Printf("❌ 地點重複出現: %s\n", stop.Stop.Name)
+			failed++
+		}
+		seen[stop.Stop.Name] = true
+		fmt.Printf("   %s（%d 個車次）下一班 %s\n", stop.Stop.Name, stop.Trips, stop.ETA.Format("01/02 15:04"))
+	}
+
+	if len(stops) == 0 || stops[0].Stop.Name != location || stops[0].Trips != 2 {
+		fmt.Printf("❌ 最近站點應為 %s 且有 2 個車次\n", location)
+		failed++
+	} else {
+		fmt.Println("✅ 最近站點已合併 2 個車次")
+	}
+
+	if len(stops) > 0 {
+		group := data.StopGroups()[stops[0].StopID]
+		if group == nil || len(group.Points) != 2 {
+			fmt.Println("❌ 站點詳情找不到所有車次")
+			failed++
+		} else {
+			for _, point := range group.Points {
+				fmt.Printf("✅ %s %s %s（%s）\n", point.ArrivalTime, point.VehicleNumber, point.VehicleTrip, point.Squad)
+			}
+		}
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Printf("✨ 共 %d 個地點，無重複！\n", len(stops))
+}
@@ -48,7 +48,7 @@ func main() {
 			fmt.Printf("✅ 意圖分析成功:\n")
 			fmt.Printf("   District: '%s'\n", intent.District)
 			fmt.Printf("   Keywords: %v\n", intent.Keywords)
-			fmt.Printf("   QueryType: '%s'\n", intent.QueryType)
+			fmt.Printf("   Intent: '%s'\n", intent.Intent)
 
 			// 檢查是否正確提取了區域
 			if strings.Contains(testAddress, intent.District) && intent.District != "" {
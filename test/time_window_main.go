@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"linebot-garbage-helper/internal/gemini"
+	"linebot-garbage-helper/internal/utils"
+)
+
+// 以固定的「現在」（2026-10-16 星期五 20:00，台灣時間）驗證時間說法轉成的時間範圍
+func main() {
+	fmt.Println("時間範圍測試")
+	fmt.Println(strings.Repeat("=", 60))
+
+	now := time.Date(2026, 10, 16, 20, 0, 0, 0, utils.GetTaiwanTimezone())
+	fmt.Printf("現在：%s\n\n", now.Format("2006-01-02 15:04 Mon"))
+
+	expressions := []struct {
+		text string
+		want gemini.TimeWindow
+	}{
+		{"晚上九點前在哪裡倒垃圾", gemini.TimeWindow{To: "2026-10-16 21:00"}},
+		{"晚上七點前", gemini.TimeWindow{To: "2026-10-17 19:00"}},
+		{"晚上七點後", gemini.TimeWindow{From: "2026-10-16 19:00"}},
+		{"早上七點", gemini.TimeWindow{From: "2026-10-17 07:00"}},
+		{"晚上七點到九點", gemini.TimeWindow{From: "2026-10-16 19:00", To: "2026-10-16 21:00"}},
+		{"晚上十一點到凌晨一點", gemini.TimeWindow{From: "2026-10-16 23:00", To: "2026-10-17 01:00"}},
+		{"30分鐘內", gemini.TimeWindow{From: "2026-10-16 20:00", To: "2026-10-16 20:30"}},
+		{"兩個小時內", gemini.TimeWindow{From: "2026-10-16 20:00", To: "2026-10-16 22:00"}},
+		{"半小時後", gemini.TimeWindow{From: "2026-10-16 20:30"}},
+		{"一個半小時內", gemini.TimeWindow{From: "2026-10-16 20:00", To: "2026-10-16 21:30"}},
+		{"兩個半小時後", gemini.TimeWindow{From: "2026-10-16 22:30"}},
+		{"現在", gemini.TimeWindow{From: "2026-10-16 20:00", To: "2026-10-16 20:30"}},
+		{"等一下", gemini.TimeWindow{From: "2026-10-16 20:00", To: "2026-10-16 21:00"}},
+		{"今晚", gemini.TimeWindow{From: "2026-10-16 20:00", To: "2026-10-17 00:00"}},
+		{"明天早上", gemini.TimeWindow{From: "2026-10-17 05:00", To: "2026-10-17 10:00"}},
+		{"明晚八點", gemini.TimeWindow{From: "2026-10-17 20:00"}},
+		{"後天", gemini.TimeWindow{From: "2026-10-18 00:00", To: "2026-10-19 00:00"}},
+		{"下週一", gemini.TimeWindow{From: "2026-10-19 00:00", To: "2026-10-20 00:00"}},
+		{"星期五晚上", gemini.TimeWindow{From: "2026-10-16 20:00", To: "2026-10-17 00:00"}},
+		{"禮拜三早上七點前", gemini.TimeWindow{From: "2026-10-21 00:00", To: "2026-10-21 07:00"}},
+		{"台北市大安區", gemini.TimeWindow{}},
+		{"晚上十二點前", gemini.TimeWindow{To: "2026-10-17 00:00"}},
+		{"七點前", gemini.TimeWindow{To: "2026-10-17 07:00"}},
+		{"一點前", gemini.TimeWindow{To: "2026-10-17 01:00"}},
+		{"九點到十點", gemini.TimeWindow{From: "2026-10-16 21:00", To: "2026-10-16 22:00"}},
+		// 「一點」是「稍微」的意思，不是時間
+		{"近一點的垃圾車", gemini.TimeWindow{}},
+		{"快一點的垃圾車", gemini.TimeWindow{}},
+		{"晚一點再倒", gemini.TimeWindow{}},
+		{"有沒有多一點的班次", gemini.TimeWindow{}},
+		{"一點的垃圾車", gemini.TimeWindow{}},
+		// 其他國字鐘點後面接「的」「有」或句子結束時是時刻
+		{"九點的垃圾車", gemini.TimeWindow{From: "2026-10-16 21:00"}},
+		{"十點有車嗎", gemini.TimeWindow{From: "2026-10-16 22:00"}},
+		{"十一點", gemini.TimeWindow{From: "2026-10-16 23:00"}},
+		{"快一點的垃圾車，晚上九點前", gemini.TimeWindow{To: "2026-10-16 21:00"}},
+	}
+
+	failed := 0
+	for _, c := range expressions {
+		got := gemini.ExtractTimeWindow(c.text, now)
+		if got != c.want {
+			fmt.Printf("❌ %-20s → %+v，預期 %+v\n", c.text, got, c.want)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %-20s → %q ～ %q\n", c.text, got.From, got.To)
+	}
+
+	// 沒有時段的鐘點取最近的一次：先試今天下午、晚上，過了才是明天早上
+	evening := now.Add(30 * time.Minute)
+	fmt.Printf("\n現在：%s\n", evening.Format("2006-01-02 15:04 Mon"))
+	for _, c := range []struct {
+		text string
+		want gemini.TimeWindow
+	}{
+		{"九點", gemini.TimeWindow{From: "2026-10-16 21:00"}},
+		{"七點前", gemini.TimeWindow{To: "2026-10-17 07:00"}},
+		{"3點", gemini.TimeWindow{From: "2026-10-17 03:00"}},
+		{"七點到九點", gemini.TimeWindow{From: "2026-10-17 07:00", To: "2026-10-17 09:00"}},
+	} {
+		got := gemini.ExtractTimeWindow(c.text, evening)
+		if got != c.want {
+			fmt.Printf("❌ %-20s → %+v，預期 %+v\n", c.text, got, c.want)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %-20s → %q ～ %q\n", c.text, got.From, got.To)
+	}
+
+	fmt.Println()
+	windows := []struct {
+		window   gemini.TimeWindow
+		from, to string
+	}{
+		{gemini.TimeWindow{From: "19:00", To: "21:00"}, "2026-10-16 19:00", "2026-10-16 21:00"},
+		{gemini.TimeWindow{To: "07:00"}, "", "2026-10-17 07:00"},
+		{gemini.TimeWindow{From: "23:00", To: "01:00"}, "2026-10-16 23:00", "2026-10-17 01:00"},
+		{gemini.TimeWindow{From: "2026-10-19 06:00", To: "09:00"}, "2026-10-19 06:00", "2026-10-19 09:00"},
+		{gemini.TimeWindow{From: "明天早上"}, "2026-10-17 05:00", ""},
+	}
+	for _, c := range windows {
+		from, to, err := gemini.ParseTimeWindowAt(c.window, now)
+		if err != nil || format(from) != c.from || format(to) != c.to {
+			fmt.Printf("❌ %+v → %q ～ %q (%v)，預期 %q ～ %q\n", c.window, format(from), format(to), err, c.from, c.to)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %+v → %q ～ %q\n", c.window, format(from), format(to))
+	}
+	if _, _, err := gemini.ParseTimeWindowAt(gemini.TimeWindow{To: "不知道"}, now); err == nil {
+		fmt.Println("❌ 無法理解的時間應該回傳錯誤")
+		failed++
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	if failed > 0 {
+		fmt.Printf("❌ %d 項失敗\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("✨ 時間範圍正確！")
+}
+
+func format(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(gemini.TimeLayout)
+}